@@ -0,0 +1,244 @@
+// Package cache provides an in-memory cache with per-entry expiration,
+// least recently used eviction and single-flight loading.
+package cache
+
+import (
+	"container/list"
+	"errors"
+	"sync"
+	"time"
+)
+
+// EvictReason tells why an entry was removed from the cache.
+type EvictReason int
+
+// errLoaderPanicked is returned to callers waiting on a load whose loader
+// panicked; the caller that ran the loader sees the panic itself.
+var errLoaderPanicked = errors.New("Cache loader panicked")
+
+const (
+	// Expired entries outlived their TTL.
+	Expired EvictReason = iota
+	// Capacity entries were the least recently used when the cache was full.
+	Capacity
+)
+
+func (r EvictReason) String() string {
+	if r == Expired {
+		return "expired"
+	}
+	return "capacity"
+}
+
+// Hooks are called on cache events, e.g. to export metrics. Any of them may
+// be nil. They are called with the cache locked and must not use it.
+type Hooks struct {
+	OnHit   func()
+	OnMiss  func()
+	OnEvict func(reason EvictReason)
+	// OnLoad is called after a GetOrLoad loader returns.
+	OnLoad func(duration time.Duration, err error)
+}
+
+type options struct {
+	maxEntries int
+	hooks      Hooks
+}
+
+// Option configures New.
+type Option func(*options)
+
+// WithMaxEntries bounds the cache to n entries, evicting the least recently
+// used one when a new key is added to a full cache. Zero, the default, means
+// no limit.
+func WithMaxEntries(n int) Option {
+	return func(o *options) {
+		o.maxEntries = n
+	}
+}
+
+// WithHooks registers callbacks for cache events.
+func WithHooks(hooks Hooks) Option {
+	return func(o *options) {
+		o.hooks = hooks
+	}
+}
+
+// Cache maps keys to values that expire after a TTL. It is safe for
+// concurrent use.
+type Cache[K comparable, V any] struct {
+	mu         sync.Mutex
+	defaultTTL time.Duration
+	options    options
+	order      *list.List
+	entries    map[K]*list.Element
+	loads      map[K]*load[V]
+}
+
+type entry[K comparable, V any] struct {
+	key       K
+	value     V
+	expiresAt time.Time
+}
+
+type load[V any] struct {
+	done  chan struct{}
+	value V
+	err   error
+}
+
+// New returns an empty cache whose entries expire after defaultTTL. Zero
+// means entries do not expire unless set with SetWithTTL.
+func New[K comparable, V any](defaultTTL time.Duration, opts ...Option) *Cache[K, V] {
+	c := &Cache[K, V]{
+		defaultTTL: defaultTTL,
+		order:      list.New(),
+		entries:    make(map[K]*list.Element),
+		loads:      make(map[K]*load[V]),
+	}
+	for _, option := range opts {
+		option(&c.options)
+	}
+	return c
+}
+
+// Get returns the value for key if present and not expired.
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.get(key)
+}
+
+func (c *Cache[K, V]) get(key K) (V, bool) {
+	element, ok := c.entries[key]
+	if ok && c.expired(element.Value.(*entry[K, V]), time.Now()) {
+		c.remove(element, Expired)
+		ok = false
+	}
+	if !ok {
+		if c.options.hooks.OnMiss != nil {
+			c.options.hooks.OnMiss()
+		}
+		var zero V
+		return zero, false
+	}
+	if c.options.hooks.OnHit != nil {
+		c.options.hooks.OnHit()
+	}
+	c.order.MoveToFront(element)
+	return element.Value.(*entry[K, V]).value, true
+}
+
+// Set stores value for key with the default TTL.
+func (c *Cache[K, V]) Set(key K, value V) {
+	c.SetWithTTL(key, value, c.defaultTTL)
+}
+
+// SetWithTTL stores value for key, expiring it after ttl. Zero or less
+// means the entry does not expire.
+func (c *Cache[K, V]) SetWithTTL(key K, value V, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.set(key, value, ttl)
+}
+
+func (c *Cache[K, V]) set(key K, value V, ttl time.Duration) {
+	var expiresAt time.Time
+	if ttl > 0 {
+		expiresAt = time.Now().Add(ttl)
+	}
+	if element, ok := c.entries[key]; ok {
+		e := element.Value.(*entry[K, V])
+		e.value, e.expiresAt = value, expiresAt
+		c.order.MoveToFront(element)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&entry[K, V]{key: key, value: value, expiresAt: expiresAt})
+	for c.options.maxEntries > 0 && c.order.Len() > c.options.maxEntries {
+		c.remove(c.order.Back(), Capacity)
+	}
+}
+
+// GetOrLoad returns the value for key, calling load to produce and store it
+// with the default TTL if it is missing or expired. Concurrent calls for the
+// same key share a single load. Errors are returned to every waiting caller
+// and are not cached.
+func (c *Cache[K, V]) GetOrLoad(key K, loader func(key K) (V, error)) (V, error) {
+	c.mu.Lock()
+	if value, ok := c.get(key); ok {
+		c.mu.Unlock()
+		return value, nil
+	}
+	if pending, ok := c.loads[key]; ok {
+		c.mu.Unlock()
+		<-pending.done
+		return pending.value, pending.err
+	}
+	pending := &load[V]{done: make(chan struct{})}
+	c.loads[key] = pending
+	c.mu.Unlock()
+
+	start, returned := time.Now(), false
+	defer func() {
+		if !returned {
+			pending.err = errLoaderPanicked
+		}
+		c.mu.Lock()
+		delete(c.loads, key)
+		if pending.err == nil {
+			c.set(key, pending.value, c.defaultTTL)
+		}
+		if c.options.hooks.OnLoad != nil {
+			c.options.hooks.OnLoad(time.Since(start), pending.err)
+		}
+		c.mu.Unlock()
+		close(pending.done)
+	}()
+	pending.value, pending.err = loader(key)
+	returned = true
+	return pending.value, pending.err
+}
+
+// Delete removes key from the cache.
+func (c *Cache[K, V]) Delete(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if element, ok := c.entries[key]; ok {
+		c.order.Remove(element)
+		delete(c.entries, key)
+	}
+}
+
+// DeleteExpired removes every expired entry. Expired entries are otherwise
+// only removed when looked up or evicted for capacity.
+func (c *Cache[K, V]) DeleteExpired() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	for element := c.order.Back(); element != nil; {
+		previous := element.Prev()
+		if c.expired(element.Value.(*entry[K, V]), now) {
+			c.remove(element, Expired)
+		}
+		element = previous
+	}
+}
+
+// Len returns the number of entries, including expired ones not yet removed.
+func (c *Cache[K, V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+func (c *Cache[K, V]) expired(e *entry[K, V], now time.Time) bool {
+	return !e.expiresAt.IsZero() && !now.Before(e.expiresAt)
+}
+
+func (c *Cache[K, V]) remove(element *list.Element, reason EvictReason) {
+	c.order.Remove(element)
+	delete(c.entries, element.Value.(*entry[K, V]).key)
+	if c.options.hooks.OnEvict != nil {
+		c.options.hooks.OnEvict(reason)
+	}
+}
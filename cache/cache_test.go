@@ -0,0 +1,166 @@
+package cache
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestExpiry(t *testing.T) {
+	var evicted []EvictReason
+	c := New[string, int](20*time.Millisecond, WithHooks(Hooks{
+		OnEvict: func(reason EvictReason) { evicted = append(evicted, reason) },
+	}))
+	c.Set("short", 1)
+	c.SetWithTTL("forever", 2, 0)
+	c.SetWithTTL("long", 3, time.Hour)
+
+	if value, ok := c.Get("short"); !ok || value != 1 {
+		t.Fatalf("Get(short) = %d, %v before it expired", value, ok)
+	}
+	time.Sleep(30 * time.Millisecond)
+	if _, ok := c.Get("short"); ok {
+		t.Error("expired entry was returned")
+	}
+	for key, want := range map[string]int{"forever": 2, "long": 3} {
+		if value, ok := c.Get(key); !ok || value != want {
+			t.Errorf("Get(%s) = %d, %v; want %d", key, value, ok, want)
+		}
+	}
+	if len(evicted) != 1 || evicted[0] != Expired {
+		t.Errorf("evictions = %v, want one expiry", evicted)
+	}
+}
+
+func TestDeleteExpired(t *testing.T) {
+	c := New[string, int](time.Millisecond)
+	c.Set("a", 1)
+	c.Set("b", 2)
+	c.SetWithTTL("c", 3, 0)
+	time.Sleep(5 * time.Millisecond)
+	if c.Len() != 3 {
+		t.Fatalf("Len = %d before DeleteExpired, want the 3 entries", c.Len())
+	}
+	c.DeleteExpired()
+	if c.Len() != 1 {
+		t.Errorf("Len = %d after DeleteExpired, want 1", c.Len())
+	}
+}
+
+func TestLRUEviction(t *testing.T) {
+	var evicted []EvictReason
+	c := New[string, int](0, WithMaxEntries(2), WithHooks(Hooks{
+		OnEvict: func(reason EvictReason) { evicted = append(evicted, reason) },
+	}))
+	c.Set("a", 1)
+	c.Set("b", 2)
+	c.Get("a")
+	c.Set("c", 3)
+
+	if _, ok := c.Get("b"); ok {
+		t.Error("least recently used entry was kept")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, ok := c.Get(key); !ok {
+			t.Errorf("%s was evicted", key)
+		}
+	}
+	if c.Len() != 2 || len(evicted) != 1 || evicted[0] != Capacity {
+		t.Errorf("Len = %d and evictions = %v; want 2 entries after one capacity eviction", c.Len(), evicted)
+	}
+
+	c.Set("a", 10)
+	if value, _ := c.Get("a"); value != 10 || c.Len() != 2 {
+		t.Errorf("updating a key: Get = %d, Len = %d", value, c.Len())
+	}
+}
+
+func TestDeleteAndLen(t *testing.T) {
+	c := New[int, string](0)
+	for i := 0; i < 3; i++ {
+		c.Set(i, strconv.Itoa(i))
+	}
+	c.Delete(1)
+	c.Delete(42)
+	if c.Len() != 2 {
+		t.Errorf("Len = %d, want 2", c.Len())
+	}
+	if _, ok := c.Get(1); ok {
+		t.Error("deleted key was returned")
+	}
+}
+
+func TestHitAndMissHooks(t *testing.T) {
+	var hits, misses int
+	c := New[string, int](0, WithHooks(Hooks{OnHit: func() { hits++ }, OnMiss: func() { misses++ }}))
+	c.Set("a", 1)
+	c.Get("a")
+	c.Get("a")
+	c.Get("b")
+	if hits != 2 || misses != 1 {
+		t.Errorf("hits = %d, misses = %d; want 2 and 1", hits, misses)
+	}
+}
+
+func TestGetOrLoad(t *testing.T) {
+	c := New[string, int](0)
+	var loads int32
+	release := make(chan struct{})
+	loader := func(string) (int, error) {
+		atomic.AddInt32(&loads, 1)
+		<-release
+		return 7, nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if value, err := c.GetOrLoad("key", loader); err != nil || value != 7 {
+				t.Errorf("GetOrLoad = %d, %v", value, err)
+			}
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	if loads != 1 {
+		t.Errorf("loader ran %d times, want once", loads)
+	}
+
+	failure := errors.New("failed")
+	if _, err := c.GetOrLoad("bad", func(string) (int, error) { return 0, failure }); err != failure {
+		t.Errorf("err = %v, want the loader error", err)
+	}
+	if _, ok := c.Get("bad"); ok {
+		t.Error("failed load was cached")
+	}
+}
+
+func TestConcurrentGetSet(t *testing.T) {
+	c := New[int, int](time.Millisecond, WithMaxEntries(50))
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				key := (g*1000 + i) % 100
+				c.Set(key, i)
+				c.Get(key)
+				if i%100 == 0 {
+					c.Delete(key)
+					c.DeleteExpired()
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	if c.Len() > 50 {
+		t.Errorf("Len = %d, want at most 50", c.Len())
+	}
+}
@@ -0,0 +1,166 @@
+// Package config populates structs from environment variables and optional
+// .env and YAML files, driven by struct tags:
+//
+//	type Config struct {
+//		Addr     string        `env:"ADDR" default:":8080"`
+//		Timeout  time.Duration `env:"TIMEOUT" default:"5s" yaml:"timeout"`
+//		Upstream *url.URL      `env:"UPSTREAM_URL" required:"true"`
+//		APIKey   string        `env:"API_KEY" secret:"true"`
+//		DB       struct {
+//			Host string `env:"HOST"`
+//		} `env:"DB_"`
+//	}
+//
+// Values are applied in increasing order of precedence: default tags, the
+// YAML file, the .env file, then the environment. The env tag of a nested
+// struct is a prefix for the variables of its fields.
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+
+	"go.uber.org/multierr"
+	"gopkg.in/yaml.v3"
+)
+
+type options struct {
+	prefix   string
+	dotEnv   []string
+	yamlFile string
+	lookup   func(string) (string, bool)
+}
+
+// Option configures Load.
+type Option func(*options)
+
+// WithPrefix prepends prefix to every variable name, e.g. "MYAPP_".
+func WithPrefix(prefix string) Option {
+	return func(o *options) {
+		o.prefix = prefix
+	}
+}
+
+// WithDotEnv reads variables from .env files, given as KEY=VALUE lines.
+// Variables already set in the environment take precedence, and the
+// environment itself is not modified. Missing files are ignored.
+func WithDotEnv(paths ...string) Option {
+	return func(o *options) {
+		o.dotEnv = append(o.dotEnv, paths...)
+	}
+}
+
+// WithYAML decodes path into the struct before variables are applied, using
+// the fields' yaml tags. A missing file is ignored.
+func WithYAML(path string) Option {
+	return func(o *options) {
+		o.yamlFile = path
+	}
+}
+
+// Load populates the struct pointed to by v. Every invalid value and missing
+// required field is reported in the returned error.
+func Load(v interface{}, opts ...Option) error {
+	o := options{lookup: os.LookupEnv}
+	for _, option := range opts {
+		option(&o)
+	}
+
+	target := reflect.ValueOf(v)
+	if target.Kind() != reflect.Ptr || target.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("Config target must be a pointer to a struct, got %T", v)
+	}
+
+	var errs error
+	walk(target.Elem(), o.prefix, func(f field) {
+		if value, ok := f.tag.Lookup("default"); ok {
+			errs = multierr.Append(errs, f.set(value))
+		}
+	})
+
+	if o.yamlFile != "" {
+		data, err := os.ReadFile(o.yamlFile)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		if err == nil {
+			if err = yaml.Unmarshal(data, v); err != nil {
+				return fmt.Errorf("Invalid YAML in %s: %v", o.yamlFile, err)
+			}
+		}
+	}
+
+	if len(o.dotEnv) > 0 {
+		values, err := readDotEnv(o.dotEnv)
+		if err != nil {
+			return err
+		}
+		o.lookup = func(key string) (string, bool) {
+			if value, ok := os.LookupEnv(key); ok {
+				return value, true
+			}
+			value, ok := values[key]
+			return value, ok
+		}
+	}
+
+	walk(target.Elem(), o.prefix, func(f field) {
+		if f.env != "" {
+			if value, ok := o.lookup(f.env); ok {
+				errs = multierr.Append(errs, f.set(value))
+			}
+		}
+		if f.tag.Get("required") == "true" && f.value.IsZero() {
+			name := f.env
+			if name == "" {
+				name = f.path
+			}
+			errs = multierr.Append(errs, fmt.Errorf("Missing required config %s", name))
+		}
+	})
+	return errs
+}
+
+// field is a leaf of the struct being loaded.
+type field struct {
+	path  string
+	env   string
+	tag   reflect.StructTag
+	value reflect.Value
+}
+
+func (f field) set(value string) error {
+	if err := setValue(f.value, value); err != nil {
+		return fmt.Errorf("Invalid value for %s: %v", f.path, err)
+	}
+	return nil
+}
+
+// walk calls fn for every settable leaf field of s, recursing into nested
+// structs that are not parsed as a whole, such as url.URL.
+func walk(s reflect.Value, prefix string, fn func(field)) {
+	walkPath(s, prefix, "", fn)
+}
+
+func walkPath(s reflect.Value, prefix, path string, fn func(field)) {
+	t := s.Type()
+	for i := 0; i < t.NumField(); i++ {
+		structField := t.Field(i)
+		if !structField.IsExported() {
+			continue
+		}
+		value := s.Field(i)
+		name := path + structField.Name
+		env, hasEnv := structField.Tag.Lookup("env")
+		if value.Kind() == reflect.Struct && !parsedWhole(value) {
+			walkPath(value, prefix+env, name+".", fn)
+			continue
+		}
+		f := field{path: name, tag: structField.Tag, value: value}
+		if hasEnv && env != "" {
+			f.env = prefix + env
+		}
+		fn(f)
+	}
+}
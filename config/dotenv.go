@@ -0,0 +1,86 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// readDotEnv parses .env files into a map. Later files override earlier
+// ones. Blank lines, comments and an "export " prefix are allowed; see
+// dotEnvValue for how values are read.
+func readDotEnv(paths []string) (map[string]string, error) {
+	values := make(map[string]string)
+	for _, path := range paths {
+		file, err := os.Open(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		scanner := bufio.NewScanner(file)
+		for line := 1; scanner.Scan(); line++ {
+			text := strings.TrimSpace(scanner.Text())
+			if text == "" || strings.HasPrefix(text, "#") {
+				continue
+			}
+			key, value, ok := strings.Cut(strings.TrimPrefix(text, "export "), "=")
+			if !ok {
+				file.Close()
+				return nil, fmt.Errorf("Invalid line %d in %s", line, path)
+			}
+			if value, err = dotEnvValue(strings.TrimSpace(value)); err != nil {
+				file.Close()
+				return nil, fmt.Errorf("Invalid value on line %d in %s: %v", line, path, err)
+			}
+			values[strings.TrimSpace(key)] = value
+		}
+		err = scanner.Err()
+		file.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return values, nil
+}
+
+// dotEnvValue reads the value of a .env line. Double-quoted values are
+// unquoted with Go escape rules and single-quoted ones are taken literally.
+// Either may be followed by a comment, as may an unquoted value once a "#" is
+// preceded by whitespace.
+func dotEnvValue(value string) (string, error) {
+	var quoted, rest string
+	switch {
+	case strings.HasPrefix(value, `"`):
+		prefix, err := strconv.QuotedPrefix(value)
+		if err != nil {
+			return "", fmt.Errorf("unterminated double-quoted value")
+		}
+		rest = value[len(prefix):]
+		if quoted, err = strconv.Unquote(prefix); err != nil {
+			return "", err
+		}
+	case strings.HasPrefix(value, "'"):
+		end := strings.IndexByte(value[1:], '\'')
+		if end < 0 {
+			return "", fmt.Errorf("unterminated single-quoted value")
+		}
+		quoted, rest = value[1:end+1], value[end+2:]
+	default:
+		if i := strings.Index(value, " #"); i >= 0 {
+			value = value[:i]
+		}
+		if i := strings.Index(value, "\t#"); i >= 0 {
+			value = value[:i]
+		}
+		return strings.TrimSpace(value), nil
+	}
+	if rest = strings.TrimSpace(rest); rest != "" && !strings.HasPrefix(rest, "#") {
+		return "", fmt.Errorf("unexpected %q after the quoted value", rest)
+	}
+	return quoted, nil
+}
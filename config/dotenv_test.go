@@ -0,0 +1,76 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeFile(t *testing.T, name, contents string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(contents), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReadDotEnv(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want string
+	}{
+		{name: "plain", line: "KEY=value", want: "value"},
+		{name: "spaces around", line: "  KEY = value  ", want: "value"},
+		{name: "export prefix", line: "export KEY=value", want: "value"},
+		{name: "empty", line: "KEY=", want: ""},
+		{name: "equals in value", line: "KEY=a=b", want: "a=b"},
+		{name: "inline comment", line: "KEY=value # note", want: "value"},
+		{name: "tab before comment", line: "KEY=value\t# note", want: "value"},
+		{name: "hash without space", line: "KEY=a#b", want: "a#b"},
+		{name: "double quoted", line: `KEY="a b"`, want: "a b"},
+		{name: "double quoted escapes", line: `KEY="line\nnext \"quoted\""`, want: "line\nnext \"quoted\""},
+		{name: "double quoted hash", line: `KEY="a # b"`, want: "a # b"},
+		{name: "double quoted comment", line: `KEY="a b" # note`, want: "a b"},
+		{name: "single quoted", line: `KEY='a \n b'`, want: `a \n b`},
+		{name: "single quoted comment", line: `KEY='a # b' # note`, want: "a # b"},
+		{name: "empty quotes", line: `KEY=""`, want: ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			values, err := readDotEnv([]string{writeFile(t, ".env", test.line+"\n")})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, ok := values["KEY"]; !ok || got != test.want {
+				t.Errorf("KEY = %q, %v; want %q", got, ok, test.want)
+			}
+		})
+	}
+}
+
+func TestReadDotEnvInvalid(t *testing.T) {
+	for _, line := range []string{
+		"NOT A PAIR",
+		`KEY="unterminated`,
+		`KEY='unterminated`,
+		`KEY="a" b`,
+	} {
+		if _, err := readDotEnv([]string{writeFile(t, ".env", line)}); err == nil {
+			t.Errorf("%q was accepted", line)
+		}
+	}
+}
+
+func TestReadDotEnvFiles(t *testing.T) {
+	first := writeFile(t, "first.env", "# settings\n\nA=1\nB=1\n")
+	second := writeFile(t, "second.env", "B=2\n")
+	values, err := readDotEnv([]string{first, filepath.Join(t.TempDir(), "missing.env"), second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(values) != 2 || values["A"] != "1" || values["B"] != "2" {
+		t.Errorf("values = %v, want A=1 and B from the later file", values)
+	}
+}
@@ -0,0 +1,77 @@
+package config
+
+import (
+	"net"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSetValue(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  interface{}
+	}{
+		{name: "string", input: "text", want: "text"},
+		{name: "bool", input: "true", want: true},
+		{name: "int", input: "-42", want: -42},
+		{name: "hex int", input: "0x1f", want: int64(31)},
+		{name: "uint8", input: "255", want: uint8(255)},
+		{name: "float", input: "1.5", want: 1.5},
+		{name: "duration", input: "1m30s", want: 90 * time.Second},
+		{name: "url", input: "https://example.com/api", want: url.URL{Scheme: "https", Host: "example.com", Path: "/api"}},
+		{name: "string slice", input: "a, b ,c", want: []string{"a", "b", "c"}},
+		{name: "int slice", input: "1,2,3", want: []int{1, 2, 3}},
+		{name: "duration slice", input: "1s,2s", want: []time.Duration{time.Second, 2 * time.Second}},
+		{name: "empty slice", input: "", want: []string{}},
+		{name: "text unmarshaler", input: "10.0.0.1", want: net.ParseIP("10.0.0.1")},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			v := reflect.New(reflect.TypeOf(test.want)).Elem()
+			if err := setValue(v, test.input); err != nil {
+				t.Fatal(err)
+			}
+			if got := v.Interface(); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("setValue(%q) = %#v, want %#v", test.input, got, test.want)
+			}
+		})
+	}
+}
+
+func TestSetValuePointer(t *testing.T) {
+	var timeout *time.Duration
+	if err := setValue(reflect.ValueOf(&timeout).Elem(), "5s"); err != nil {
+		t.Fatal(err)
+	}
+	if timeout == nil || *timeout != 5*time.Second {
+		t.Errorf("timeout = %v, want 5s", timeout)
+	}
+}
+
+func TestSetValueInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		zero  interface{}
+	}{
+		{name: "bool", input: "maybe", zero: false},
+		{name: "int", input: "ten", zero: 0},
+		{name: "int overflow", input: "300", zero: int8(0)},
+		{name: "negative uint", input: "-1", zero: uint(0)},
+		{name: "duration without unit", input: "5", zero: time.Duration(0)},
+		{name: "relative url", input: "/path", zero: url.URL{}},
+		{name: "slice element", input: "1,x", zero: []int(nil)},
+		{name: "unsupported", input: "x", zero: map[string]string(nil)},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			v := reflect.New(reflect.TypeOf(test.zero)).Elem()
+			if err := setValue(v, test.input); err == nil {
+				t.Errorf("setValue(%q) into %T succeeded", test.input, test.zero)
+			}
+		})
+	}
+}
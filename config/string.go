@@ -0,0 +1,48 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"strings"
+)
+
+const redacted = "REDACTED"
+
+// String formats a config struct as space-separated name=value pairs with
+// fields tagged secret:"true" redacted, so it is safe to log. Config types
+// can use it to implement fmt.Stringer:
+//
+//	func (c Config) String() string { return config.String(c) }
+func String(v interface{}) string {
+	value := reflect.Indirect(reflect.ValueOf(v))
+	if value.Kind() != reflect.Struct {
+		return fmt.Sprint(v)
+	}
+
+	var pairs []string
+	walk(value, "", func(f field) {
+		text := redacted
+		if f.tag.Get("secret") != "true" || f.value.IsZero() {
+			text = fmt.Sprint(display(f.value))
+		}
+		pairs = append(pairs, f.path+"="+text)
+	})
+	return strings.Join(pairs, " ")
+}
+
+// display unwraps pointers so their target is printed rather than their
+// address. A URL's password is redacted.
+func display(v reflect.Value) interface{} {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return "<nil>"
+		}
+		v = v.Elem()
+	}
+	if v.Type() == urlType {
+		u := v.Interface().(url.URL)
+		return u.Redacted()
+	}
+	return v.Interface()
+}
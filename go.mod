@@ -1,5 +1,29 @@
 module github.com/metamemelord/go-utilities
 
-go 1.13
+go 1.19
 
-require go.uber.org/multierr v1.5.0
+require (
+	github.com/klauspost/compress v1.11.13
+	github.com/prometheus/client_golang v1.12.2
+	github.com/sirupsen/logrus v1.9.3
+	go.opentelemetry.io/otel v1.14.0
+	go.opentelemetry.io/otel/trace v1.14.0
+	go.uber.org/multierr v1.6.0
+	go.uber.org/zap v1.21.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require (
+	github.com/beorn7/perks v1.0.1 // indirect
+	github.com/cespare/xxhash/v2 v2.1.2 // indirect
+	github.com/go-logr/logr v1.2.3 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
+	github.com/golang/protobuf v1.5.2 // indirect
+	github.com/matttproud/golang_protobuf_extensions v1.0.1 // indirect
+	github.com/prometheus/client_model v0.2.0 // indirect
+	github.com/prometheus/common v0.32.1 // indirect
+	github.com/prometheus/procfs v0.7.3 // indirect
+	go.uber.org/atomic v1.7.0 // indirect
+	golang.org/x/sys v0.0.0-20220715151400-c0bba94af5f8 // indirect
+	google.golang.org/protobuf v1.26.0 // indirect
+)
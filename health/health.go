@@ -0,0 +1,193 @@
+// Package health serves liveness and readiness endpoints that aggregate the
+// results of named checks into a JSON status.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Check reports whether a dependency is healthy by returning nil.
+type Check func(ctx context.Context) error
+
+// CheckOption configures a single check.
+type CheckOption func(*check)
+
+// WithCheckTimeout overrides the Checker's timeout for a check.
+func WithCheckTimeout(d time.Duration) CheckOption {
+	return func(c *check) {
+		c.timeout = d
+	}
+}
+
+// WithCacheFor reuses a check's result for d instead of running it on every
+// probe, for checks that are expensive or hit rate limited dependencies.
+func WithCacheFor(d time.Duration) CheckOption {
+	return func(c *check) {
+		c.cacheFor = d
+	}
+}
+
+type check struct {
+	name     string
+	fn       Check
+	timeout  time.Duration
+	cacheFor time.Duration
+
+	mu      sync.Mutex
+	result  Result
+	checked time.Time
+}
+
+// Result is the outcome of one check.
+type Result struct {
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration"`
+}
+
+// Report is the JSON body served by the handlers.
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]Result `json:"checks,omitempty"`
+}
+
+const (
+	statusOK   = "ok"
+	statusFail = "fail"
+)
+
+// Checker holds liveness and readiness checks. It is safe for concurrent use.
+type Checker struct {
+	mu        sync.Mutex
+	timeout   time.Duration
+	liveness  []*check
+	readiness []*check
+}
+
+// New returns a Checker whose checks time out after timeout unless
+// configured otherwise. Zero uses 5 seconds.
+func New(timeout time.Duration) *Checker {
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	return &Checker{timeout: timeout}
+}
+
+// AddLivenessCheck registers a check served by LivenessHandler. Liveness
+// checks should only fail when the process cannot recover by itself.
+func (c *Checker) AddLivenessCheck(name string, fn Check, options ...CheckOption) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.liveness = append(c.liveness, c.newCheck(name, fn, options))
+}
+
+// AddReadinessCheck registers a check served by ReadinessHandler, typically
+// for a dependency the service needs to handle traffic.
+func (c *Checker) AddReadinessCheck(name string, fn Check, options ...CheckOption) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.readiness = append(c.readiness, c.newCheck(name, fn, options))
+}
+
+func (c *Checker) newCheck(name string, fn Check, options []CheckOption) *check {
+	ch := &check{name: name, fn: fn, timeout: c.timeout}
+	for _, option := range options {
+		option(ch)
+	}
+	return ch
+}
+
+// LivenessHandler serves the liveness checks, conventionally at /healthz.
+func (c *Checker) LivenessHandler() http.Handler {
+	return c.handler(func() []*check { return c.liveness })
+}
+
+// ReadinessHandler serves the readiness checks, conventionally at /readyz.
+func (c *Checker) ReadinessHandler() http.Handler {
+	return c.handler(func() []*check { return c.readiness })
+}
+
+// Register serves LivenessHandler at /healthz and ReadinessHandler at /readyz
+// on mux.
+func (c *Checker) Register(mux *http.ServeMux) {
+	mux.Handle("/healthz", c.LivenessHandler())
+	mux.Handle("/readyz", c.ReadinessHandler())
+}
+
+// handler runs the checks concurrently and responds 200 if all of them pass
+// and 503 otherwise.
+func (c *Checker) handler(checks func() []*check) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.mu.Lock()
+		registered := append([]*check(nil), checks()...)
+		c.mu.Unlock()
+
+		report := run(r.Context(), registered)
+		status := http.StatusOK
+		if report.Status != statusOK {
+			status = http.StatusServiceUnavailable
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(report)
+	})
+}
+
+func run(ctx context.Context, checks []*check) Report {
+	report := Report{Status: statusOK, Checks: make(map[string]Result, len(checks))}
+	results := make([]Result, len(checks))
+	var wg sync.WaitGroup
+	wg.Add(len(checks))
+	for i, ch := range checks {
+		go func(i int, ch *check) {
+			defer wg.Done()
+			results[i] = ch.run(ctx)
+		}(i, ch)
+	}
+	wg.Wait()
+
+	for i, ch := range checks {
+		report.Checks[ch.name] = results[i]
+		if results[i].Status != statusOK {
+			report.Status = statusFail
+		}
+	}
+	return report
+}
+
+// run returns the cached result if it is recent enough, and otherwise runs
+// the check. Probes of the same check wait for each other, so with caching
+// concurrent probes share one run; without it each probe runs the check.
+func (c *check) run(ctx context.Context) Result {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.cacheFor > 0 && time.Since(c.checked) < c.cacheFor {
+		return c.result
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		done <- c.fn(ctx)
+	}()
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	c.result = Result{Status: statusOK, Duration: time.Since(start).String()}
+	if err != nil {
+		c.result.Status, c.result.Error = statusFail, err.Error()
+	}
+	c.checked = time.Now()
+	return c.result
+}
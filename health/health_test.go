@@ -0,0 +1,218 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func probe(t *testing.T, handler http.Handler) (int, Report) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if contentType := w.Header().Get("Content-Type"); contentType != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", contentType)
+	}
+	if cacheControl := w.Header().Get("Cache-Control"); cacheControl != "no-store" {
+		t.Errorf("Cache-Control = %q, want no-store", cacheControl)
+	}
+	var report Report
+	if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+		t.Fatal(err)
+	}
+	return w.Code, report
+}
+
+func passing(context.Context) error { return nil }
+
+func TestAggregation(t *testing.T) {
+	tests := []struct {
+		name   string
+		checks map[string]Check
+		code   int
+		status string
+	}{
+		{name: "no checks", checks: nil, code: http.StatusOK, status: statusOK},
+		{name: "all pass", checks: map[string]Check{"db": passing, "cache": passing}, code: http.StatusOK, status: statusOK},
+		{
+			name: "one fails",
+			checks: map[string]Check{
+				"db":    passing,
+				"cache": func(context.Context) error { return errors.New("connection refused") },
+			},
+			code:   http.StatusServiceUnavailable,
+			status: statusFail,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := New(time.Second)
+			for name, fn := range test.checks {
+				c.AddReadinessCheck(name, fn)
+			}
+			code, report := probe(t, c.ReadinessHandler())
+			if code != test.code || report.Status != test.status {
+				t.Errorf("got %d %s, want %d %s", code, report.Status, test.code, test.status)
+			}
+			if len(report.Checks) != len(test.checks) {
+				t.Fatalf("report has %d checks, want %d", len(report.Checks), len(test.checks))
+			}
+			for name, result := range report.Checks {
+				if result.Duration == "" {
+					t.Errorf("%s has no duration", name)
+				}
+				if (result.Status == statusFail) != (result.Error != "") {
+					t.Errorf("%s = %+v, want an error exactly when it fails", name, result)
+				}
+			}
+			if result, ok := report.Checks["cache"]; test.status == statusFail && (!ok || result.Error != "connection refused") {
+				t.Errorf("cache = %+v, want the check's error", result)
+			}
+		})
+	}
+}
+
+func TestRegisterSeparatesLivenessAndReadiness(t *testing.T) {
+	c := New(time.Second)
+	c.AddLivenessCheck("goroutines", passing)
+	c.AddReadinessCheck("db", func(context.Context) error { return errors.New("down") })
+	mux := http.NewServeMux()
+	c.Register(mux)
+
+	tests := []struct {
+		path  string
+		code  int
+		check string
+	}{
+		{"/healthz", http.StatusOK, "goroutines"},
+		{"/readyz", http.StatusServiceUnavailable, "db"},
+	}
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, test.path, nil))
+		var report Report
+		json.NewDecoder(w.Body).Decode(&report)
+		if _, ok := report.Checks[test.check]; w.Code != test.code || !ok || len(report.Checks) != 1 {
+			t.Errorf("%s = %d %+v, want %d with only %s", test.path, w.Code, report, test.code, test.check)
+		}
+	}
+}
+
+func TestChecksRunConcurrently(t *testing.T) {
+	var started sync.WaitGroup
+	started.Add(2)
+	wait := func(ctx context.Context) error {
+		started.Done()
+		done := make(chan struct{})
+		go func() {
+			started.Wait()
+			close(done)
+		}()
+		select {
+		case <-done:
+			return nil
+		case <-ctx.Done():
+			return errors.New("the other check did not start")
+		}
+	}
+	c := New(time.Second)
+	c.AddReadinessCheck("a", wait)
+	c.AddReadinessCheck("b", wait)
+	if code, report := probe(t, c.ReadinessHandler()); code != http.StatusOK {
+		t.Errorf("got %d %+v, want both checks to run at once", code, report)
+	}
+}
+
+func TestTimeouts(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	c := New(20 * time.Millisecond)
+	c.AddReadinessCheck("ignores context", func(context.Context) error {
+		<-release
+		return nil
+	})
+	c.AddReadinessCheck("honors context", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	c.AddReadinessCheck("slow but allowed", func(ctx context.Context) error {
+		select {
+		case <-time.After(50 * time.Millisecond):
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}, WithCheckTimeout(time.Second))
+
+	start := time.Now()
+	_, report := probe(t, c.ReadinessHandler())
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("probe took %v, want the timeout to bound it", elapsed)
+	}
+	for _, name := range []string{"ignores context", "honors context"} {
+		if result := report.Checks[name]; result.Status != statusFail || !strings.Contains(result.Error, "deadline exceeded") {
+			t.Errorf("%s = %+v, want a deadline failure", name, result)
+		}
+	}
+	if result := report.Checks["slow but allowed"]; result.Status != statusOK {
+		t.Errorf("slow but allowed = %+v, want WithCheckTimeout to extend the timeout", result)
+	}
+}
+
+func TestCaching(t *testing.T) {
+	var cached, uncached int32
+	c := New(time.Second)
+	c.AddReadinessCheck("cached", func(context.Context) error {
+		atomic.AddInt32(&cached, 1)
+		return nil
+	}, WithCacheFor(time.Hour))
+	c.AddReadinessCheck("uncached", func(context.Context) error {
+		atomic.AddInt32(&uncached, 1)
+		return nil
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			probe(t, c.ReadinessHandler())
+		}()
+	}
+	wg.Wait()
+	if cached != 1 {
+		t.Errorf("cached check ran %d times, want 1", cached)
+	}
+	if uncached != 5 {
+		t.Errorf("uncached check ran %d times, want once per probe", uncached)
+	}
+}
+
+func TestCacheExpires(t *testing.T) {
+	var calls int32
+	c := New(time.Second)
+	c.AddLivenessCheck("flaky", func(context.Context) error {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			return errors.New("first probe fails")
+		}
+		return nil
+	}, WithCacheFor(20*time.Millisecond))
+
+	if code, _ := probe(t, c.LivenessHandler()); code != http.StatusServiceUnavailable {
+		t.Fatalf("first probe = %d, want 503", code)
+	}
+	if code, _ := probe(t, c.LivenessHandler()); code != http.StatusServiceUnavailable {
+		t.Errorf("cached probe = %d, want the cached failure", code)
+	}
+	time.Sleep(30 * time.Millisecond)
+	if code, _ := probe(t, c.LivenessHandler()); code != http.StatusOK || calls != 2 {
+		t.Errorf("probe after expiry = %d with %d calls, want a fresh passing run", code, calls)
+	}
+}
@@ -0,0 +1,24 @@
+package health
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/metamemelord/go-utilities/http/request"
+)
+
+// HTTPCheck returns a check that passes when a GET to url answers with a 2xx
+// status. The request is sent with the request builder over its shared
+// connection pool, is not retried and is bounded by the check timeout.
+func HTTPCheck(url string) Check {
+	return func(ctx context.Context) error {
+		response, err := request.Get(url).SetContext(ctx).SetRetries(0).SetAttemptTimeout(0).DoWrapped()
+		if err != nil {
+			return err
+		}
+		if !response.IsSuccess() {
+			return fmt.Errorf("%s returned %s", url, response.Raw().Status)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,50 @@
+package health
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/metamemelord/go-utilities/http/request"
+)
+
+func TestHTTPCheck(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/down" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	if err := HTTPCheck(server.URL + "/up")(context.Background()); err != nil {
+		t.Errorf("healthy endpoint: %v", err)
+	}
+	err := HTTPCheck(server.URL + "/down")(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "500 Internal Server Error") {
+		t.Errorf("failing endpoint: err = %v, want the status", err)
+	}
+}
+
+func TestHTTPCheckIsNotRetried(t *testing.T) {
+	request.SetDefaults(request.Defaults{Timeout: time.Second, Retries: 3})
+	defer request.SetDefaults(request.Defaults{Timeout: 30 * time.Second})
+
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		conn, _, _ := w.(http.Hijacker).Hijack()
+		conn.Close()
+	}))
+	defer server.Close()
+
+	if err := HTTPCheck(server.URL)(context.Background()); err == nil {
+		t.Fatal("check passed against a server that drops connections")
+	}
+	if hits := atomic.LoadInt32(&hits); hits != 1 {
+		t.Errorf("server was hit %d times, want 1", hits)
+	}
+}
@@ -0,0 +1,38 @@
+package request
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// AsyncResult is the outcome of a request started with DoAsync or run in a
+// Batch.
+type AsyncResult struct {
+	Response *http.Response
+	Err      error
+}
+
+// DoAsync sends the request in a new goroutine and returns a channel that
+// receives its result once, then is closed. Canceling the request context set
+// with SetContext aborts the call. A panic during the call is recovered and
+// delivered as an error. The builder must not be used until the result has
+// been received.
+func (h *httpRequest) DoAsync() <-chan AsyncResult {
+	results := make(chan AsyncResult, 1)
+	go func() {
+		defer close(results)
+		results <- h.doRecovered()
+	}()
+	return results
+}
+
+// doRecovered calls Do, turning a panic into an error.
+func (h *httpRequest) doRecovered() (result AsyncResult) {
+	defer func() {
+		if r := recover(); r != nil {
+			result = AsyncResult{Err: fmt.Errorf("Request panicked: %v", r)}
+		}
+	}()
+	response, err := h.Do()
+	return AsyncResult{Response: response, Err: err}
+}
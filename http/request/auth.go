@@ -0,0 +1,86 @@
+package request
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/url"
+)
+
+const redacted = "[REDACTED]"
+
+// SetBasicAuth sets the Authorization header for HTTP basic authentication.
+func (h *httpRequest) SetBasicAuth(username, password string) *httpRequest {
+	credentials := base64.StdEncoding.EncodeToString([]byte(username + ":" + password))
+	return h.setSensitiveHeader("Authorization", "Basic "+credentials)
+}
+
+// SetBearerToken sets the Authorization header to a bearer token.
+func (h *httpRequest) SetBearerToken(token string) *httpRequest {
+	return h.setSensitiveHeader("Authorization", "Bearer "+token)
+}
+
+// SetAPIKey sends key in the header headerName, e.g. SetAPIKey("X-API-Key", key).
+func (h *httpRequest) SetAPIKey(headerName, key string) *httpRequest {
+	return h.setSensitiveHeader(headerName, key)
+}
+
+// setSensitiveHeader sets a header whose value must never be logged.
+func (h *httpRequest) setSensitiveHeader(key, value string) *httpRequest {
+	if h.sensitiveHeaders == nil {
+		h.sensitiveHeaders = make(map[string]bool)
+	}
+	h.sensitiveHeaders[http.CanonicalHeaderKey(key)] = true
+	return h.SetHeader(key, value)
+}
+
+// credentialHeaders are redacted even when set with SetHeader.
+var credentialHeaders = map[string]bool{
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+	"Cookie":              true,
+	"Set-Cookie":          true,
+}
+
+// carriesCredentials reports whether the request is sent with credentials,
+// in its headers, its URL, or added while sending it, so its response may be
+// private to the caller.
+func (h *httpRequest) carriesCredentials() bool {
+	if h.request.URL != nil && h.request.URL.User != nil {
+		return true
+	}
+	if h.oauth2 != nil || h.signer != nil || h.jar != nil || len(h.envHeaders) > 0 {
+		return true
+	}
+	for key := range h.request.Header {
+		if credentialHeaders[key] || h.sensitiveHeaders[key] {
+			return true
+		}
+	}
+	return false
+}
+
+// redactedHeader returns a copy of header that is safe to log, with the values
+// of credential headers replaced.
+func (h *httpRequest) redactedHeader(header http.Header) http.Header {
+	safe := header.Clone()
+	for key := range safe {
+		if h.sensitiveHeaders[key] || credentialHeaders[key] {
+			safe[key] = []string{redacted}
+		}
+	}
+	return safe
+}
+
+// redactedURL returns u as a string with any password in its user info
+// replaced.
+func redactedURL(u *url.URL) string {
+	if u == nil || u.User == nil {
+		return u.String()
+	}
+	if _, ok := u.User.Password(); !ok {
+		return u.String()
+	}
+	safe := *u
+	safe.User = url.UserPassword(u.User.Username(), redacted)
+	return safe.String()
+}
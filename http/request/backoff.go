@@ -0,0 +1,84 @@
+package request
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/metamemelord/go-utilities/retry"
+)
+
+// Backoff returns how long to wait before the given retry, where retry 1 is
+// the first attempt after the initial request.
+type Backoff = retry.Backoff
+
+// ConstantBackoff waits the same delay before every retry.
+func ConstantBackoff(delay time.Duration) Backoff {
+	return retry.Constant(delay)
+}
+
+// ExponentialBackoff doubles the delay before every retry, starting at base,
+// and never waits longer than max.
+func ExponentialBackoff(base, max time.Duration) Backoff {
+	return retry.Exponential(base, max)
+}
+
+// ExponentialJitterBackoff waits a random delay between zero and the delay
+// ExponentialBackoff would use, spreading out retries from many clients.
+func ExponentialJitterBackoff(base, max time.Duration) Backoff {
+	return retry.ExponentialJitter(base, max)
+}
+
+// SetBackoff sets the delay strategy used between retries. Without one,
+// retries are sent back to back.
+func (h *httpRequest) SetBackoff(backoff Backoff) *httpRequest {
+	h.backoff = backoff
+	return h
+}
+
+// IgnoreRetryAfter makes retries use the backoff strategy even when a 429 or
+// 503 response carries a Retry-After header.
+func (h *httpRequest) IgnoreRetryAfter() *httpRequest {
+	h.ignoreRetryAfter = true
+	return h
+}
+
+// SetMaxRetryAfter caps how long a Retry-After header can make a retry wait,
+// so a misbehaving server cannot stall the caller. Zero means no cap.
+func (h *httpRequest) SetMaxRetryAfter(d time.Duration) *httpRequest {
+	h.maxRetryAfter = d
+	return h
+}
+
+// retryAfter returns the wait requested by a 429 or 503 response through its
+// Retry-After header, given either in seconds or as an HTTP date, capped by
+// SetMaxRetryAfter.
+func (h *httpRequest) retryAfter(response *http.Response) time.Duration {
+	wait := h.requestedRetryAfter(response)
+	if h.maxRetryAfter > 0 && wait > h.maxRetryAfter {
+		return h.maxRetryAfter
+	}
+	return wait
+}
+
+func (h *httpRequest) requestedRetryAfter(response *http.Response) time.Duration {
+	if h.ignoreRetryAfter || response == nil {
+		return 0
+	}
+	if response.StatusCode != http.StatusTooManyRequests &&
+		response.StatusCode != http.StatusServiceUnavailable {
+		return 0
+	}
+
+	value := response.Header.Get("Retry-After")
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return time.Until(date)
+	}
+	return 0
+}
@@ -0,0 +1,51 @@
+package request
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSetMaxRetryAfter(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.Header().Set("Retry-After", "86400")
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	start := time.Now()
+	response, err := Get(server.URL).SetRetries(1).SetRetryPolicy(RetryOnStatus(http.StatusServiceUnavailable)).
+		SetMaxRetryAfter(50 * time.Millisecond).Do()
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond || elapsed > 2*time.Second {
+		t.Errorf("waited %v, want about the 50ms cap", elapsed)
+	}
+	if response.StatusCode != http.StatusOK || calls != 2 {
+		t.Errorf("status = %d after %d calls, want 200 after 2", response.StatusCode, calls)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	tooMany := &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{"Retry-After": {"120"}}}
+	for _, test := range []struct {
+		builder *httpRequest
+		want    time.Duration
+	}{
+		{Get("http://example.com"), 120 * time.Second},
+		{Get("http://example.com").SetMaxRetryAfter(time.Second), time.Second},
+		{Get("http://example.com").SetMaxRetryAfter(time.Hour), 120 * time.Second},
+		{Get("http://example.com").IgnoreRetryAfter(), 0},
+	} {
+		if got := test.builder.retryAfter(tooMany); got != test.want {
+			t.Errorf("retryAfter = %v, want %v", got, test.want)
+		}
+	}
+}
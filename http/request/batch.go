@@ -0,0 +1,51 @@
+package request
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/metamemelord/go-utilities/pool"
+)
+
+// Batch sends a set of requests concurrently with bounded parallelism.
+type Batch struct {
+	requests []*httpRequest
+	workers  int
+}
+
+// NewBatch returns an empty batch that runs at most workers requests at a
+// time. Zero or less runs all of them at once.
+func NewBatch(workers int) *Batch {
+	return &Batch{workers: workers}
+}
+
+// Add appends requests to the batch.
+func (b *Batch) Add(requests ...*httpRequest) *Batch {
+	b.requests = append(b.requests, requests...)
+	return b
+}
+
+// Do sends every request in the batch and waits for all of them to finish.
+// The results are in the order the requests were added. A failed request
+// does not stop the others; cancel their contexts to abort early. A panic
+// during a request is recovered and reported as its error.
+func (b *Batch) Do() []AsyncResult {
+	workers := b.workers
+	if workers <= 0 || workers > len(b.requests) {
+		workers = len(b.requests)
+	}
+
+	p := pool.New[*http.Response](workers)
+	for _, request := range b.requests {
+		request := request
+		p.Submit(func(context.Context) (*http.Response, error) {
+			return request.Do()
+		})
+	}
+
+	results := make([]AsyncResult, len(b.requests))
+	for i, result := range p.Wait() {
+		results[i] = AsyncResult{Response: result.Value, Err: result.Err}
+	}
+	return results
+}
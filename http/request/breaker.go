@@ -0,0 +1,124 @@
+package request
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// CircuitBreakerOptions configures SetCircuitBreaker.
+type CircuitBreakerOptions struct {
+	// FailureThreshold is the number of consecutive failures that opens the
+	// circuit. Defaults to 5.
+	FailureThreshold int
+	// CoolDown is how long the circuit stays open before a single probe
+	// request is let through. Defaults to 30 seconds.
+	CoolDown time.Duration
+	// IsFailure classifies an attempt. Defaults to transport errors and 5xx
+	// responses.
+	IsFailure func(response *http.Response, err error) bool
+}
+
+type circuitState int
+
+const (
+	circuitClosed circuitState = iota
+	circuitOpen
+	circuitHalfOpen
+)
+
+type circuitBreaker struct {
+	mu       sync.Mutex
+	state    circuitState
+	failures int
+	openedAt time.Time
+}
+
+var (
+	breakersMutex sync.Mutex
+	breakers      = make(map[string]*circuitBreaker)
+)
+
+// breakerFor returns the breaker shared by all requests to host.
+func breakerFor(host string) *circuitBreaker {
+	breakersMutex.Lock()
+	defer breakersMutex.Unlock()
+	breaker, ok := breakers[host]
+	if !ok {
+		breaker = &circuitBreaker{}
+		breakers[host] = breaker
+	}
+	return breaker
+}
+
+func (b *circuitBreaker) allow(options *CircuitBreakerOptions) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case circuitOpen:
+		if time.Since(b.openedAt) < options.CoolDown {
+			return ErrCircuitOpen
+		}
+		b.state = circuitHalfOpen
+		return nil
+	case circuitHalfOpen:
+		// A probe is already in flight.
+		return ErrCircuitOpen
+	}
+	return nil
+}
+
+func (b *circuitBreaker) record(options *CircuitBreakerOptions, failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !failed {
+		b.state = circuitClosed
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.state == circuitHalfOpen || b.failures >= options.FailureThreshold {
+		b.state = circuitOpen
+		b.openedAt = time.Now()
+	}
+}
+
+func defaultIsFailure(response *http.Response, err error) bool {
+	return err != nil || response.StatusCode >= 500
+}
+
+// SetCircuitBreaker guards the request with a circuit breaker shared by every
+// request to the same host. After FailureThreshold consecutive failures,
+// requests fail fast with ErrCircuitOpen for CoolDown, after which one probe
+// is allowed through to decide whether to close the circuit again.
+func (h *httpRequest) SetCircuitBreaker(options CircuitBreakerOptions) *httpRequest {
+	if options.FailureThreshold <= 0 {
+		options.FailureThreshold = 5
+	}
+	if options.CoolDown <= 0 {
+		options.CoolDown = 30 * time.Second
+	}
+	if options.IsFailure == nil {
+		options.IsFailure = defaultIsFailure
+	}
+	h.circuitBreaker = &options
+	return h
+}
+
+// guardCircuit wraps roundTrip with the request's circuit breaker, if any.
+func (h *httpRequest) guardCircuit(roundTrip RoundTripFunc) RoundTripFunc {
+	options := h.circuitBreaker
+	if options == nil {
+		return roundTrip
+	}
+	return func(request *http.Request) (*http.Response, error) {
+		breaker := breakerFor(request.URL.Host)
+		if err := breaker.allow(options); err != nil {
+			closeBody(request)
+			return nil, err
+		}
+		response, err := roundTrip(request)
+		breaker.record(options, options.IsFailure(response, err))
+		return response, err
+	}
+}
@@ -0,0 +1,73 @@
+package request
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// closeRecorder is a request body that records whether it was closed.
+type closeRecorder struct {
+	io.Reader
+	closed bool
+}
+
+func (b *closeRecorder) Close() error {
+	b.closed = true
+	return nil
+}
+
+func newBodyRequest(t *testing.T, uri string) (*http.Request, *closeRecorder) {
+	t.Helper()
+	body := &closeRecorder{Reader: strings.NewReader("payload")}
+	request, err := http.NewRequest(http.MethodPost, uri, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return request, body
+}
+
+// resetBreaker forgets the circuit breaker state of host, so that the test
+// starts from a closed circuit whenever it runs.
+func resetBreaker(host string) {
+	breakersMutex.Lock()
+	delete(breakers, host)
+	breakersMutex.Unlock()
+}
+
+func TestCircuitBreakerOpens(t *testing.T) {
+	resetBreaker("breaker-opens.test")
+	failing := func(*http.Request) (*http.Response, error) { return nil, errors.New("connection refused") }
+	roundTrip := Get("http://breaker-opens.test").
+		SetCircuitBreaker(CircuitBreakerOptions{FailureThreshold: 2}).guardCircuit(failing)
+
+	for i := 0; i < 2; i++ {
+		request, _ := newBodyRequest(t, "http://breaker-opens.test")
+		if _, err := roundTrip(request); err == ErrCircuitOpen {
+			t.Fatalf("circuit opened after %d failures, want 2", i)
+		}
+	}
+	request, _ := newBodyRequest(t, "http://breaker-opens.test")
+	if _, err := roundTrip(request); err != ErrCircuitOpen {
+		t.Errorf("err = %v, want ErrCircuitOpen", err)
+	}
+}
+
+func TestCircuitBreakerClosesRejectedBody(t *testing.T) {
+	resetBreaker("breaker-body.test")
+	failing := func(*http.Request) (*http.Response, error) { return nil, errors.New("connection refused") }
+	roundTrip := Get("http://breaker-body.test").
+		SetCircuitBreaker(CircuitBreakerOptions{FailureThreshold: 1}).guardCircuit(failing)
+
+	request, _ := newBodyRequest(t, "http://breaker-body.test")
+	roundTrip(request)
+	request, body := newBodyRequest(t, "http://breaker-body.test")
+	if _, err := roundTrip(request); err != ErrCircuitOpen {
+		t.Fatalf("err = %v, want ErrCircuitOpen", err)
+	}
+	if !body.closed {
+		t.Error("body of the rejected request was not closed")
+	}
+}
@@ -0,0 +1,115 @@
+package request
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"go.uber.org/multierr"
+)
+
+// Validate checks the builder and returns every problem found at once: the
+// errors recorded by setters, a missing or non-HTTP URL, a body on a method
+// that does not take one, and a body without a Content-Type.
+func (h *httpRequest) Validate() error {
+	err := h.err
+
+	u := h.request.URL
+	switch {
+	case u == nil || u.String() == "":
+		err = multierr.Append(err, ErrMissingURL)
+	case !u.IsAbs():
+		err = multierr.Append(err, fmt.Errorf("URL %s is not absolute", redactedURL(u)))
+	case u.Scheme != "http" && u.Scheme != "https":
+		err = multierr.Append(err, fmt.Errorf("Unsupported URL scheme %q", u.Scheme))
+	case u.Host == "":
+		err = multierr.Append(err, fmt.Errorf("URL %s has no host", redactedURL(u)))
+	}
+
+	if h.hasBody() {
+		switch h.request.Method {
+		case "", http.MethodGet, http.MethodHead, http.MethodTrace:
+			err = multierr.Append(err, fmt.Errorf("%s requests must not have a body", h.method()))
+		}
+		if len(h.formParts) == 0 && h.request.Header.Get("Content-Type") == "" {
+			err = multierr.Append(err, errors.New("Request body has no Content-Type"))
+		}
+	}
+
+	if h.maxPayloadSize > 0 && int64(len(h.payload)) > h.maxPayloadSize {
+		err = multierr.Append(err, fmt.Errorf("%w: exceeds the maximum size of %d bytes", ErrPayloadTooLarge, h.maxPayloadSize))
+	}
+	return err
+}
+
+func (h *httpRequest) hasBody() bool {
+	if h.payload != nil {
+		return len(h.payload) > 0
+	}
+	return h.request.Body != nil || h.bodySource != nil || h.streamingBody()
+}
+
+func (h *httpRequest) method() string {
+	if h.request.Method == "" {
+		return http.MethodGet
+	}
+	return h.request.Method
+}
+
+// Request is a request built with Build. It cannot be modified and may be
+// sent any number of times, including from several goroutines at once.
+type Request struct {
+	template *httpRequest
+}
+
+// Build checks the builder with Validate and returns a Request holding a
+// snapshot of its configuration; later changes to the builder do not affect
+// it. A body set from a reader is buffered so it can be resent. Bodies
+// streamed from a channel and form files added from a reader cannot be
+// resent, and make Build fail.
+func (h *httpRequest) Build() (*Request, error) {
+	if err := h.Validate(); err != nil {
+		return nil, err
+	}
+	if h.bodyChannel != nil {
+		return nil, errors.New("Request bodies streamed from a channel cannot be reused")
+	}
+	for _, part := range h.formParts {
+		if part.reader != nil {
+			return nil, errors.New("Form files added from a reader cannot be reused")
+		}
+	}
+	if h.payload == nil && h.bodySource == nil && h.request.Body != nil {
+		payload, err := ioutil.ReadAll(h.request.Body)
+		h.request.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		h.bufferPayload(payload)
+	}
+	return &Request{template: h.Clone()}, nil
+}
+
+// Do sends a copy of the request, like the builder's Do.
+func (r *Request) Do() (*http.Response, error) {
+	return r.template.copyBuilder().Do()
+}
+
+// DoContext is like Do but sends the request with ctx.
+func (r *Request) DoContext(ctx context.Context) (*http.Response, error) {
+	return r.template.copyBuilder().SetContext(ctx).Do()
+}
+
+// DoWrapped sends a copy of the request and reads the response, like the
+// builder's DoWrapped.
+func (r *Request) DoWrapped() (*Response, error) {
+	return r.template.copyBuilder().DoWrapped()
+}
+
+// Builder returns a new builder initialized with the request's configuration,
+// for deriving a variant of it.
+func (r *Request) Builder() *httpRequest {
+	return r.template.Clone()
+}
@@ -0,0 +1,216 @@
+package request
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/metamemelord/go-utilities/cache"
+)
+
+// CachedResponse is a response held by a CacheStore. Its fields are exported
+// so stores backed by external systems can serialize it.
+type CachedResponse struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+	// FreshUntil is when the response must be revalidated with the server.
+	FreshUntil time.Time
+}
+
+// CacheStore holds cached responses by key. Implementations must be safe for
+// concurrent use.
+type CacheStore interface {
+	Get(key string) (*CachedResponse, bool)
+	Set(key string, response *CachedResponse)
+	Delete(key string)
+}
+
+// LRUCache is an in-memory CacheStore that evicts the least recently used
+// response once it holds more than its capacity.
+type LRUCache struct {
+	entries *cache.Cache[string, *CachedResponse]
+}
+
+// NewLRUCache returns an LRUCache holding at most capacity responses.
+func NewLRUCache(capacity int) *LRUCache {
+	return &LRUCache{entries: cache.New[string, *CachedResponse](0, cache.WithMaxEntries(capacity))}
+}
+
+// Get implements CacheStore.
+func (c *LRUCache) Get(key string) (*CachedResponse, bool) {
+	return c.entries.Get(key)
+}
+
+// Set implements CacheStore. Stale responses are kept for revalidation until
+// they are evicted.
+func (c *LRUCache) Set(key string, response *CachedResponse) {
+	c.entries.Set(key, response)
+}
+
+// Delete implements CacheStore.
+func (c *LRUCache) Delete(key string) {
+	c.entries.Delete(key)
+}
+
+// SetCache serves GET responses from store while they are fresh according to
+// their Cache-Control or Expires headers, and revalidates stale responses
+// that carry an ETag or Last-Modified header with a conditional request.
+// Responses marked no-store or private, or varying by request headers, are not
+// cached. Requests carrying credentials, such as an Authorization, Cookie or
+// API key header, bypass the cache, since store may be shared by callers.
+func (h *httpRequest) SetCache(store CacheStore) *httpRequest {
+	h.cache = store
+	return h
+}
+
+func (h *httpRequest) doCached() (*http.Response, error) {
+	if h.request.URL == nil || h.request.Header.Get("If-None-Match") != "" || h.request.Header.Get("If-Modified-Since") != "" ||
+		h.carriesCredentials() {
+		return h.roundTrip()
+	}
+	key := h.request.URL.String()
+
+	cached, ok := h.cache.Get(key)
+	if ok && time.Now().Before(cached.FreshUntil) {
+		return h.cachedResponse(cached), nil
+	}
+	if ok {
+		etag, lastModified := cached.Header.Get("ETag"), cached.Header.Get("Last-Modified")
+		if etag == "" && lastModified == "" {
+			ok = false
+		}
+		if etag != "" {
+			h.request.Header.Set("If-None-Match", etag)
+			defer h.request.Header.Del("If-None-Match")
+		}
+		if lastModified != "" {
+			h.request.Header.Set("If-Modified-Since", lastModified)
+			defer h.request.Header.Del("If-Modified-Since")
+		}
+	}
+
+	response, err := h.roundTrip()
+	if err != nil {
+		return nil, err
+	}
+
+	if ok && response.StatusCode == http.StatusNotModified {
+		response.Body.Close()
+		revalidated := *cached
+		revalidated.Header = cached.Header.Clone()
+		for name, values := range response.Header {
+			revalidated.Header[name] = values
+		}
+		revalidated.FreshUntil = freshUntil(revalidated.Header, time.Now())
+		h.cache.Set(key, &revalidated)
+		return h.cachedResponse(&revalidated), nil
+	}
+
+	if response.StatusCode != http.StatusOK || !cacheable(response.Header) {
+		if ok {
+			h.cache.Delete(key)
+		}
+		return response, nil
+	}
+
+	body, err := ioutil.ReadAll(response.Body)
+	response.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	response.Body = newBufferedBody(body)
+	h.cache.Set(key, &CachedResponse{
+		StatusCode: response.StatusCode,
+		Header:     response.Header.Clone(),
+		Body:       body,
+		FreshUntil: freshUntil(response.Header, time.Now()),
+	})
+	return response, nil
+}
+
+func (h *httpRequest) cachedResponse(cached *CachedResponse) *http.Response {
+	return &http.Response{
+		Status:        strconv.Itoa(cached.StatusCode) + " " + http.StatusText(cached.StatusCode),
+		StatusCode:    cached.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        cached.Header.Clone(),
+		Body:          newBufferedBody(cached.Body),
+		ContentLength: int64(len(cached.Body)),
+		Request:       h.request,
+	}
+}
+
+// cacheable reports whether a response may be stored: it must not be marked
+// no-store or private or vary by request headers, and must be either fresh
+// for a while or revalidatable.
+func cacheable(header http.Header) bool {
+	directives := cacheControl(header)
+	if _, ok := directives["no-store"]; ok {
+		return false
+	}
+	if _, ok := directives["private"]; ok {
+		return false
+	}
+	if header.Get("Vary") != "" {
+		return false
+	}
+	if header.Get("ETag") != "" || header.Get("Last-Modified") != "" {
+		return true
+	}
+	return time.Now().Before(freshUntil(header, time.Now()))
+}
+
+// freshUntil returns when a response received at now goes stale, based on
+// the max-age directive or the Expires header. Responses marked no-cache are
+// stale immediately.
+func freshUntil(header http.Header, now time.Time) time.Time {
+	directives := cacheControl(header)
+	if _, ok := directives["no-cache"]; ok {
+		return now
+	}
+	if maxAge, ok := directives["max-age"]; ok {
+		seconds, err := strconv.Atoi(maxAge)
+		if err != nil {
+			return now
+		}
+		if age, err := strconv.Atoi(header.Get("Age")); err == nil {
+			seconds -= age
+		}
+		return now.Add(time.Duration(seconds) * time.Second)
+	}
+	if expires := header.Get("Expires"); expires != "" {
+		expiry, err := http.ParseTime(expires)
+		if err != nil {
+			return now
+		}
+		if date, err := http.ParseTime(header.Get("Date")); err == nil {
+			return now.Add(expiry.Sub(date))
+		}
+		return expiry
+	}
+	return now
+}
+
+// cacheControl parses the Cache-Control header into its directives.
+func cacheControl(header http.Header) map[string]string {
+	directives := make(map[string]string)
+	for _, value := range header["Cache-Control"] {
+		for _, directive := range strings.Split(value, ",") {
+			directive = strings.TrimSpace(directive)
+			if directive == "" {
+				continue
+			}
+			name, arg := directive, ""
+			if i := strings.IndexByte(directive, '='); i >= 0 {
+				name, arg = directive[:i], strings.Trim(directive[i+1:], `"`)
+			}
+			directives[strings.ToLower(name)] = arg
+		}
+	}
+	return directives
+}
@@ -0,0 +1,78 @@
+package request
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func cacheServer(calls *int32, cacheControl string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(calls, 1)
+		w.Header().Set("Cache-Control", cacheControl)
+		w.Write([]byte(r.Header.Get("Authorization")))
+	}))
+}
+
+func getBody(t *testing.T, builder *httpRequest) string {
+	t.Helper()
+	response, err := builder.Do()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(body)
+}
+
+func TestCacheServesFreshResponses(t *testing.T) {
+	var calls int32
+	server := cacheServer(&calls, "max-age=60")
+	defer server.Close()
+
+	store := NewLRUCache(10)
+	for i := 0; i < 3; i++ {
+		getBody(t, Get(server.URL).SetCache(store))
+	}
+	if calls != 1 {
+		t.Errorf("server saw %d requests, want 1", calls)
+	}
+}
+
+func TestCacheBypassedWithCredentials(t *testing.T) {
+	var calls int32
+	server := cacheServer(&calls, "max-age=60")
+	defer server.Close()
+
+	store := NewLRUCache(10)
+	if body := getBody(t, Get(server.URL).SetCache(store).SetBearerToken("alice")); body != "Bearer alice" {
+		t.Errorf("body = %q, want %q", body, "Bearer alice")
+	}
+	if body := getBody(t, Get(server.URL).SetCache(store).SetBearerToken("bob")); body != "Bearer bob" {
+		t.Errorf("body = %q, want %q", body, "Bearer bob")
+	}
+	if body := getBody(t, Get(server.URL).SetCache(store)); body != "" {
+		t.Errorf("anonymous request got %q", body)
+	}
+	if calls != 3 {
+		t.Errorf("server saw %d requests, want 3", calls)
+	}
+}
+
+func TestCacheSkipsPrivateResponses(t *testing.T) {
+	var calls int32
+	server := cacheServer(&calls, "private, max-age=60")
+	defer server.Close()
+
+	store := NewLRUCache(10)
+	getBody(t, Get(server.URL).SetCache(store))
+	getBody(t, Get(server.URL).SetCache(store))
+	if calls != 2 {
+		t.Errorf("server saw %d requests, want 2", calls)
+	}
+}
@@ -0,0 +1,54 @@
+package request
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// SetAcceptCharset sets the Accept-Charset header from charsets in order of
+// preference. The first charset is sent without a quality factor and each
+// following one gets a quality 0.1 lower, down to a minimum of 0.1.
+func (h *httpRequest) SetAcceptCharset(charsets ...string) *httpRequest {
+	values := make([]string, 0, len(charsets))
+	for i, charset := range charsets {
+		if i == 0 {
+			values = append(values, charset)
+			continue
+		}
+		quality := 10 - i
+		if quality < 1 {
+			quality = 1
+		}
+		values = append(values, charset+";q=0."+strconv.Itoa(quality))
+	}
+	return h.SetHeader("Accept-Charset", strings.Join(values, ", "))
+}
+
+// RequireUTF8Response makes Do fail if the response declares a charset other
+// than UTF-8 in its Content-Type. Responses without a charset are accepted.
+func (h *httpRequest) RequireUTF8Response() *httpRequest {
+	h.requireUTF8 = true
+	return h
+}
+
+func checkUTF8Response(response *http.Response) error {
+	contentType := response.Header.Get("Content-Type")
+	if contentType == "" {
+		return nil
+	}
+	_, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return fmt.Errorf("Invalid response Content-Type %q: %v", contentType, err)
+	}
+	charset, ok := params["charset"]
+	if !ok {
+		return nil
+	}
+	if !strings.EqualFold(charset, "utf-8") && !strings.EqualFold(charset, "utf8") {
+		return fmt.Errorf("Response charset %s is not UTF-8", charset)
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package request
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSetAcceptCharset(t *testing.T) {
+	builder := Get("http://example.com").SetAcceptCharset("utf-8", "iso-8859-1", "windows-1252")
+	if got, want := builder.request.Header.Get("Accept-Charset"), "utf-8, iso-8859-1;q=0.9, windows-1252;q=0.8"; got != want {
+		t.Errorf("Accept-Charset = %q, want %q", got, want)
+	}
+}
+
+func TestRequireUTF8Response(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if contentType := r.URL.Query().Get("type"); contentType != "" {
+			w.Header().Set("Content-Type", contentType)
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	for contentType, accepted := range map[string]bool{
+		"":                                 true,
+		"text/plain":                       true,
+		"text/plain; charset=UTF-8":        true,
+		"text/plain; charset=utf8":         true,
+		"text/plain; charset=iso-8859-1":   false,
+		"text/plain; charset=windows-1252": false,
+	} {
+		response, err := Get(server.URL).AddQueryParam("type", contentType).RequireUTF8Response().Do()
+		if err == nil {
+			response.Body.Close()
+		}
+		if accepted != (err == nil) {
+			t.Errorf("Content-Type %q: err = %v, accepted = %v", contentType, err, accepted)
+		}
+	}
+}
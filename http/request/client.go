@@ -0,0 +1,188 @@
+package request
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/multierr"
+)
+
+// Profile is the outbound policy for one downstream service. Its tags let
+// profiles be loaded with the config package, from YAML or from environment
+// variables under a per-profile prefix:
+//
+//	var payments request.Profile
+//	err := config.Load(&payments, config.WithPrefix("PAYMENTS_"))
+type Profile struct {
+	// BaseURL is prepended to the path of every request, e.g.
+	// "https://payments.internal/v1".
+	BaseURL string `yaml:"base_url" env:"BASE_URL" required:"true"`
+	// Timeout bounds each attempt. Zero keeps the package default.
+	Timeout time.Duration `yaml:"timeout" env:"TIMEOUT"`
+	// Headers are set on every request.
+	Headers map[string]string `yaml:"headers"`
+
+	BearerToken string `yaml:"bearer_token" env:"BEARER_TOKEN" secret:"true"`
+	Username    string `yaml:"username" env:"USERNAME"`
+	Password    string `yaml:"password" env:"PASSWORD" secret:"true"`
+
+	// Retries is how many times a failed attempt is retried. RetryOnStatus
+	// additionally retries responses with these status codes, and
+	// BackoffBase and BackoffMax, when set, space retries with exponential
+	// jitter backoff.
+	Retries       uint8         `yaml:"retries" env:"RETRIES"`
+	RetryOnStatus []int         `yaml:"retry_on_status" env:"RETRY_ON_STATUS"`
+	BackoffBase   time.Duration `yaml:"backoff_base" env:"BACKOFF_BASE"`
+	BackoffMax    time.Duration `yaml:"backoff_max" env:"BACKOFF_MAX"`
+
+	// RateLimit throttles requests to the host to this many per second with
+	// bursts of up to RateBurst. Zero means no limit.
+	RateLimit float64 `yaml:"rate_limit" env:"RATE_LIMIT"`
+	RateBurst int     `yaml:"rate_burst" env:"RATE_BURST"`
+
+	// CircuitBreakerThreshold, when set, opens the host's circuit breaker
+	// after this many consecutive failures for CircuitBreakerCoolDown.
+	CircuitBreakerThreshold int           `yaml:"circuit_breaker_threshold" env:"CIRCUIT_BREAKER_THRESHOLD"`
+	CircuitBreakerCoolDown  time.Duration `yaml:"circuit_breaker_cool_down" env:"CIRCUIT_BREAKER_COOL_DOWN"`
+}
+
+// apply configures h with the profile.
+func (p Profile) apply(h *httpRequest) {
+	if p.Timeout > 0 {
+		h.SetTimeout(p.Timeout)
+	}
+	for key, value := range p.Headers {
+		h.SetHeader(key, value)
+	}
+	if p.BearerToken != "" {
+		h.SetBearerToken(p.BearerToken)
+	} else if p.Username != "" {
+		h.SetBasicAuth(p.Username, p.Password)
+	}
+	if p.Retries > 0 {
+		h.SetRetries(p.Retries)
+		if len(p.RetryOnStatus) > 0 {
+			h.SetRetryPolicy(RetryOnStatus(p.RetryOnStatus...))
+		}
+		if p.BackoffBase > 0 {
+			h.SetBackoff(ExponentialJitterBackoff(p.BackoffBase, p.BackoffMax))
+		}
+	}
+	if p.RateLimit > 0 {
+		h.SetRateLimit(p.RateLimit, p.RateBurst)
+	}
+	if p.CircuitBreakerThreshold > 0 {
+		h.SetCircuitBreaker(CircuitBreakerOptions{
+			FailureThreshold: p.CircuitBreakerThreshold,
+			CoolDown:         p.CircuitBreakerCoolDown,
+		})
+	}
+}
+
+// Client holds named profiles and mints requests configured by them, so
+// outbound policy lives in one place:
+//
+//	client := request.NewClient(map[string]request.Profile{"payments": payments})
+//	response, err := client.For("payments").Post("/charges").SetJSONPayload(charge).DoWrapped()
+//
+// It is safe for concurrent use.
+type Client struct {
+	mu       sync.RWMutex
+	profiles map[string]Profile
+	tracker  *requestTracker
+}
+
+// NewClient returns a Client with the given profiles by name.
+func NewClient(profiles map[string]Profile) *Client {
+	c := &Client{profiles: make(map[string]Profile, len(profiles)), tracker: newRequestTracker()}
+	for name, profile := range profiles {
+		c.profiles[name] = profile
+	}
+	return c
+}
+
+// SetProfile adds or replaces the profile called name. Requests already
+// created keep the profile they were created with.
+func (c *Client) SetProfile(name string, profile Profile) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.profiles[name] = profile
+	return c
+}
+
+// Shutdown cancels every in-flight request made through c, closes idle
+// connections and blocks until all cancelled requests have finished or ctx
+// expires. A request stays in flight until its response body is closed.
+// Requests made through c sent afterwards fail with ErrShutdown.
+func (c *Client) Shutdown(ctx context.Context) error {
+	return c.tracker.shutdown(ctx)
+}
+
+// Host creates requests for one profile.
+type Host struct {
+	name    string
+	profile Profile
+	ok      bool
+	tracker *requestTracker
+}
+
+// For returns the requests factory of the profile called name. Requests
+// created for an unknown profile fail when sent.
+func (c *Client) For(name string) *Host {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	profile, ok := c.profiles[name]
+	return &Host{name: name, profile: profile, ok: ok, tracker: c.tracker}
+}
+
+// NewRequest returns a request for method and path, which is resolved
+// against the profile's base URL and may carry a query string.
+func (h *Host) NewRequest(method, path string) *httpRequest {
+	if !h.ok {
+		request := newRequest(method, "")
+		request.tracker = h.tracker
+		request.err = multierr.Append(request.err, fmt.Errorf("Unknown host profile %q", h.name))
+		return request
+	}
+	request := newRequest(method, joinURL(h.profile.BaseURL, path))
+	request.tracker = h.tracker
+	h.profile.apply(request)
+	return request
+}
+
+func (h *Host) Get(path string) *httpRequest {
+	return h.NewRequest(http.MethodGet, path)
+}
+
+func (h *Host) Head(path string) *httpRequest {
+	return h.NewRequest(http.MethodHead, path)
+}
+
+func (h *Host) Post(path string) *httpRequest {
+	return h.NewRequest(http.MethodPost, path)
+}
+
+func (h *Host) Put(path string) *httpRequest {
+	return h.NewRequest(http.MethodPut, path)
+}
+
+func (h *Host) Patch(path string) *httpRequest {
+	return h.NewRequest(http.MethodPatch, path)
+}
+
+func (h *Host) Delete(path string) *httpRequest {
+	return h.NewRequest(http.MethodDelete, path)
+}
+
+// joinURL appends path to base with exactly one slash between them, keeping
+// any path in base.
+func joinURL(base, path string) string {
+	if path == "" {
+		return base
+	}
+	return strings.TrimRight(base, "/") + "/" + strings.TrimLeft(path, "/")
+}
@@ -0,0 +1,166 @@
+package request
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJoinURL(t *testing.T) {
+	tests := []struct {
+		base, path, want string
+	}{
+		{"https://api.test", "/charges", "https://api.test/charges"},
+		{"https://api.test/", "/charges", "https://api.test/charges"},
+		{"https://api.test", "charges", "https://api.test/charges"},
+		{"https://api.test/", "charges", "https://api.test/charges"},
+		{"https://api.test//", "//charges", "https://api.test/charges"},
+		{"https://api.test/v1", "/charges", "https://api.test/v1/charges"},
+		{"https://api.test/v1/", "charges/42/", "https://api.test/v1/charges/42/"},
+		{"https://api.test/v1", "/charges?limit=10", "https://api.test/v1/charges?limit=10"},
+		{"https://api.test/v1", "", "https://api.test/v1"},
+		{"https://api.test/v1/", "", "https://api.test/v1/"},
+	}
+	for _, test := range tests {
+		if got := joinURL(test.base, test.path); got != test.want {
+			t.Errorf("joinURL(%q, %q) = %q, want %q", test.base, test.path, got, test.want)
+		}
+	}
+}
+
+func TestProfileApplied(t *testing.T) {
+	var seen *http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = r
+	}))
+	defer server.Close()
+
+	client := NewClient(map[string]Profile{
+		"bearer": {
+			BaseURL:     server.URL + "/v1/",
+			Timeout:     7 * time.Second,
+			Headers:     map[string]string{"X-Team": "payments"},
+			BearerToken: "token",
+			Username:    "ignored",
+			Retries:     2,
+			RateLimit:   100,
+			RateBurst:   5,
+
+			CircuitBreakerThreshold: 3,
+		},
+		"basic": {BaseURL: server.URL, Username: "user", Password: "pass"},
+	})
+
+	tests := []struct {
+		profile       string
+		path          string
+		authorization string
+		team          string
+	}{
+		{"bearer", "/charges", "Bearer token", "payments"},
+		{"basic", "charges", "Basic " + base64.StdEncoding.EncodeToString([]byte("user:pass")), ""},
+	}
+	for _, test := range tests {
+		response, err := client.For(test.profile).Get(test.path).Do()
+		if err != nil {
+			t.Fatalf("%s: %v", test.profile, err)
+		}
+		response.Body.Close()
+		if got := seen.Header.Get("Authorization"); got != test.authorization {
+			t.Errorf("%s: Authorization = %q, want %q", test.profile, got, test.authorization)
+		}
+		if got := seen.Header.Get("X-Team"); got != test.team {
+			t.Errorf("%s: X-Team = %q, want %q", test.profile, got, test.team)
+		}
+	}
+	if seen.URL.Path != "/charges" {
+		t.Errorf("basic: path = %q, want /charges", seen.URL.Path)
+	}
+
+	builder := client.For("bearer").Post("charges")
+	if builder.timeout != 7*time.Second || builder.retries != 2 {
+		t.Errorf("timeout, retries = %v, %d; want 7s, 2", builder.timeout, builder.retries)
+	}
+	if builder.rateLimit != 100 || builder.rateBurst != 5 {
+		t.Errorf("rate limit = %v/%d, want 100/5", builder.rateLimit, builder.rateBurst)
+	}
+	if builder.circuitBreaker == nil || builder.circuitBreaker.FailureThreshold != 3 {
+		t.Errorf("circuit breaker = %+v, want a threshold of 3", builder.circuitBreaker)
+	}
+	if defaulted := client.For("basic").Get("/"); defaulted.timeout != defaults.Timeout || defaulted.circuitBreaker != nil {
+		t.Errorf("profile without a timeout or breaker changed the defaults")
+	}
+}
+
+func TestProfileRetryOnStatus(t *testing.T) {
+	tests := []struct {
+		name    string
+		profile Profile
+		status  int
+	}{
+		{"retried status", Profile{Retries: 1, RetryOnStatus: []int{503}, BackoffBase: time.Millisecond}, http.StatusOK},
+		{"errors only", Profile{Retries: 1}, http.StatusServiceUnavailable},
+		{"status without retries", Profile{RetryOnStatus: []int{503}}, http.StatusServiceUnavailable},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := okServer()
+			defer server.Close()
+			test.profile.BaseURL = server.URL
+			client := NewClient(map[string]Profile{"svc": test.profile})
+
+			response, err := client.For("svc").Get("/flaky").Do()
+			if err != nil {
+				t.Fatal(err)
+			}
+			response.Body.Close()
+			if response.StatusCode != test.status {
+				t.Errorf("status = %d, want %d", response.StatusCode, test.status)
+			}
+		})
+	}
+}
+
+func TestUnknownProfile(t *testing.T) {
+	client := NewClient(nil)
+	_, err := client.For("missing").Get("/").Do()
+	if err == nil || !strings.Contains(err.Error(), `Unknown host profile "missing"`) {
+		t.Errorf("err = %v, want an unknown profile error", err)
+	}
+}
+
+func TestSetProfile(t *testing.T) {
+	client := NewClient(map[string]Profile{"svc": {BaseURL: "https://old.test"}})
+	old := client.For("svc")
+	client.SetProfile("svc", Profile{BaseURL: "https://new.test"})
+
+	if got := old.Get("/").request.URL.Host; got != "old.test" {
+		t.Errorf("existing Host resolved to %q, want old.test", got)
+	}
+	if got := client.For("svc").Get("/").request.URL.Host; got != "new.test" {
+		t.Errorf("new Host resolved to %q, want new.test", got)
+	}
+}
+
+func TestClientShutdownIsPerClient(t *testing.T) {
+	server := okServer()
+	defer server.Close()
+	profiles := map[string]Profile{"svc": {BaseURL: server.URL}}
+	stopped, running := NewClient(profiles), NewClient(profiles)
+
+	if err := stopped.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stopped.For("svc").Get("/").Do(); err != ErrShutdown {
+		t.Errorf("stopped client err = %v, want ErrShutdown", err)
+	}
+	response, err := running.For("svc").Get("/").Do()
+	if err != nil {
+		t.Fatalf("other client failed: %v", err)
+	}
+	response.Body.Close()
+}
@@ -0,0 +1,86 @@
+package request
+
+import "net/url"
+
+// Clone returns a deep copy of the request builder, so a base request holding
+// the URL, headers, timeout and policies can be specialized per call without
+// affecting other copies. Clones of the same base may be made concurrently as
+// long as the base itself is not modified or sent meanwhile.
+//
+// A payload set with SetPayload, one of the helpers built on it, or
+// SetPayloadFromFile is copied. Bodies set from a reader or channel can only
+// be read once and are not copied, and the readers of file parts added with
+// AddFormFile are shared. The client, custom transport and cookie jar are
+// shared, not copied.
+func (h *httpRequest) Clone() *httpRequest {
+	return h.copyBuilder()
+}
+
+func (h *httpRequest) copyBuilder() *httpRequest {
+	c := *h
+	c.bytesSent = 0
+	c.bytesReceived = 0
+	c.attempts = 0
+	c.lastStatus = 0
+	c.responseCookies = nil
+
+	c.request = h.request.Clone(h.request.Context())
+	c.request.Body = nil
+	c.request.GetBody = nil
+	c.request.ContentLength = 0
+	c.bodyChannel = nil
+	if h.payload != nil {
+		c.installBody(h.payload)
+	}
+
+	c.header = make(map[string]string, len(h.header))
+	for key, value := range h.header {
+		c.header[key] = value
+	}
+	if h.query != nil {
+		c.query = make(url.Values, len(h.query))
+		for key, values := range h.query {
+			c.query[key] = append([]string(nil), values...)
+		}
+	}
+	if h.replacedQuery != nil {
+		c.replacedQuery = make(map[string]bool, len(h.replacedQuery))
+		for key := range h.replacedQuery {
+			c.replacedQuery[key] = true
+		}
+	}
+	if h.pathParams != nil {
+		c.pathParams = make(map[string]string, len(h.pathParams))
+		for key, value := range h.pathParams {
+			c.pathParams[key] = value
+		}
+	}
+	if h.tags != nil {
+		c.tags = make(map[string]string, len(h.tags))
+		for key, value := range h.tags {
+			c.tags[key] = value
+		}
+	}
+	if h.sensitiveHeaders != nil {
+		c.sensitiveHeaders = make(map[string]bool, len(h.sensitiveHeaders))
+		for key := range h.sensitiveHeaders {
+			c.sensitiveHeaders[key] = true
+		}
+	}
+
+	c.envHeaders = append([]envHeader(nil), h.envHeaders...)
+	c.middleware = append([]Middleware(nil), h.middleware...)
+	c.formParts = append([]formPart(nil), h.formParts...)
+
+	if h.circuitBreaker != nil {
+		options := *h.circuitBreaker
+		c.circuitBreaker = &options
+	}
+	return &c
+}
+
+// NewFromTemplate returns a new request builder initialized with a copy of
+// base's configuration. See Clone.
+func NewFromTemplate(base *httpRequest) *httpRequest {
+	return base.Clone()
+}
@@ -0,0 +1,94 @@
+package request
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"go.uber.org/multierr"
+)
+
+// SetRequestCompression compresses the request body with the given format
+// ("gzip", "deflate" or "zstd") and sets the matching Content-Encoding. The
+// compressed bytes are buffered so retries resend them unchanged. An
+// unsupported format is reported by Do.
+func (h *httpRequest) SetRequestCompression(format string) *httpRequest {
+	if format != "gzip" &&
+		format != "deflate" &&
+		format != "zstd" {
+		h.err = multierr.Append(h.err, fmt.Errorf("Invalid/Unsupported compression format %q", format))
+		return h
+	}
+	h.compression = format
+	return h
+}
+
+// SetCompressPayload gzips the request body when compress is true. It is
+// shorthand for SetRequestCompression("gzip").
+func (h *httpRequest) SetCompressPayload(compress bool) *httpRequest {
+	if !compress {
+		h.compression = ""
+		return h
+	}
+	return h.SetRequestCompression("gzip")
+}
+
+func compressPayload(format string, payload []byte) ([]byte, error) {
+	var buffer bytes.Buffer
+	var writer io.WriteCloser
+	var err error
+
+	switch format {
+	case "gzip":
+		writer = gzip.NewWriter(&buffer)
+	case "deflate":
+		writer = zlib.NewWriter(&buffer)
+	case "zstd":
+		writer, err = zstd.NewWriter(&buffer)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("Unsupported compression format %s", format)
+	}
+
+	if _, err = writer.Write(payload); err != nil {
+		return nil, err
+	}
+	if err = writer.Close(); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// decompressBody decodes a response body according to its Content-Encoding.
+// The boolean result reports whether the body was encoded.
+func decompressBody(encoding string, body []byte) ([]byte, bool, error) {
+	var reader io.ReadCloser
+	var err error
+
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "gzip", "x-gzip":
+		reader, err = gzip.NewReader(bytes.NewReader(body))
+	case "deflate":
+		// Servers disagree on whether deflate means zlib or raw deflate data.
+		if reader, err = zlib.NewReader(bytes.NewReader(body)); err != nil {
+			reader, err = flate.NewReader(bytes.NewReader(body)), nil
+		}
+	default:
+		return body, false, nil
+	}
+	if err != nil {
+		return nil, true, err
+	}
+	defer reader.Close()
+
+	decoded, err := ioutil.ReadAll(reader)
+	return decoded, true, err
+}
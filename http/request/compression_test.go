@@ -0,0 +1,57 @@
+package request
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+func TestSetRequestCompression(t *testing.T) {
+	var encoding, body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encoding = r.Header.Get("Content-Encoding")
+		var reader io.Reader
+		var err error
+		switch encoding {
+		case "gzip":
+			reader, err = gzip.NewReader(r.Body)
+		case "deflate":
+			reader, err = zlib.NewReader(r.Body)
+		case "zstd":
+			var decoder *zstd.Decoder
+			if decoder, err = zstd.NewReader(r.Body); err == nil {
+				defer decoder.Close()
+				reader = decoder
+			}
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		decoded, _ := ioutil.ReadAll(reader)
+		body = string(decoded)
+	}))
+	defer server.Close()
+
+	for _, format := range []string{"gzip", "deflate", "zstd"} {
+		encoding, body = "", ""
+		response, err := Post(server.URL).SetPayload([]byte("payload")).SetRequestCompression(format).Do()
+		if err != nil {
+			t.Fatal(err)
+		}
+		response.Body.Close()
+		if response.StatusCode != http.StatusOK || encoding != format || body != "payload" {
+			t.Errorf("%s: server got status %d, encoding %q and body %q", format, response.StatusCode, encoding, body)
+		}
+	}
+
+	if _, err := Post(server.URL).SetPayload([]byte("payload")).SetRequestCompression("brotli").Do(); err == nil {
+		t.Error("unsupported format was accepted")
+	}
+}
@@ -0,0 +1,167 @@
+package request
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strings"
+	"time"
+
+	"go.uber.org/multierr"
+)
+
+// sharedJar is the in-memory jar used by UseSharedCookieJar.
+var sharedJar, _ = cookiejar.New(nil)
+
+// SetCookieJar stores cookies set by responses in jar and sends the matching
+// ones with the request, including across redirects. Requests sharing a jar
+// share a session.
+func (h *httpRequest) SetCookieJar(jar http.CookieJar) *httpRequest {
+	h.jar = jar
+	return h
+}
+
+// UseSharedCookieJar is like SetCookieJar with an in-memory jar shared by
+// every request that calls it.
+func (h *httpRequest) UseSharedCookieJar() *httpRequest {
+	return h.SetCookieJar(sharedJar)
+}
+
+// persistedCookie is a cookie with the attributes the jar needs to send it
+// only where the server meant it to be sent. Domain is the host it was set
+// for when HostOnly is true, and the domain it applies to otherwise.
+type persistedCookie struct {
+	Name     string     `json:"name"`
+	Value    string     `json:"value"`
+	Path     string     `json:"path,omitempty"`
+	Domain   string     `json:"domain"`
+	HostOnly bool       `json:"hostOnly,omitempty"`
+	Expires  *time.Time `json:"expires,omitempty"`
+	Secure   bool       `json:"secure,omitempty"`
+	HttpOnly bool       `json:"httpOnly,omitempty"`
+}
+
+// LoadCookiesFromFile adds the unexpired cookies stored in path by
+// SaveCookiesToFile to the request's cookie jar, creating an in-memory jar if
+// none is set, so each cookie is only sent to the domain and path it was set
+// for. Call SetCookieJar first to load them into a specific jar. A missing or
+// invalid file is reported by Do.
+func (h *httpRequest) LoadCookiesFromFile(path string) *httpRequest {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		h.err = multierr.Append(h.err, fmt.Errorf("Could not read cookie file: %w", err))
+		return h
+	}
+
+	var cookies []persistedCookie
+	if err = json.Unmarshal(contents, &cookies); err != nil {
+		h.err = multierr.Append(h.err, fmt.Errorf("Invalid cookie file %s: %w", path, err))
+		return h
+	}
+
+	if h.jar == nil {
+		jar, _ := cookiejar.New(nil)
+		h.SetCookieJar(jar)
+	}
+	now := time.Now()
+	for _, cookie := range cookies {
+		if cookie.Domain == "" || cookie.Expires != nil && cookie.Expires.Before(now) {
+			continue
+		}
+		u := &url.URL{Scheme: "http", Host: cookie.Domain, Path: cookie.Path}
+		if cookie.Secure {
+			u.Scheme = "https"
+		}
+		restored := &http.Cookie{
+			Name:     cookie.Name,
+			Value:    cookie.Value,
+			Path:     cookie.Path,
+			Secure:   cookie.Secure,
+			HttpOnly: cookie.HttpOnly,
+		}
+		if !cookie.HostOnly {
+			restored.Domain = cookie.Domain
+		}
+		if cookie.Expires != nil {
+			restored.Expires = *cookie.Expires
+		}
+		h.jar.SetCookies(u, []*http.Cookie{restored})
+	}
+	return h
+}
+
+// SaveCookiesToFile writes the cookies sent with the request, including those
+// from its cookie jar, updated with any Set-Cookie headers from the last
+// response, to path as JSON so a later process can restore the session with
+// LoadCookiesFromFile. Cookies set by a response keep their attributes; the
+// others are saved for the request's host, since a jar does not expose the
+// attributes of the cookies it holds.
+func (h *httpRequest) SaveCookiesToFile(path string) error {
+	type cookieKey struct{ name, domain, path string }
+	cookies := make(map[cookieKey]persistedCookie)
+	var order []cookieKey
+	add := func(cookie persistedCookie) {
+		key := cookieKey{cookie.Name, cookie.Domain, cookie.Path}
+		if _, ok := cookies[key]; !ok {
+			order = append(order, key)
+		}
+		cookies[key] = cookie
+	}
+
+	var host string
+	if h.request.URL != nil {
+		host = h.request.URL.Hostname()
+	}
+	sent := h.request.Cookies()
+	if h.jar != nil && h.request.URL != nil {
+		sent = append(sent, h.jar.Cookies(h.request.URL)...)
+	}
+	for _, cookie := range sent {
+		add(persistedCookie{Name: cookie.Name, Value: cookie.Value, Path: "/", Domain: host, HostOnly: true})
+	}
+
+	for _, cookie := range h.responseCookies {
+		persisted := persistedCookie{
+			Name:     cookie.Name,
+			Value:    cookie.Value,
+			Path:     cookie.Path,
+			Domain:   strings.TrimPrefix(cookie.Domain, "."),
+			Secure:   cookie.Secure,
+			HttpOnly: cookie.HttpOnly,
+		}
+		if persisted.Path == "" {
+			persisted.Path = "/"
+		}
+		if persisted.Domain == "" {
+			persisted.Domain, persisted.HostOnly = host, true
+		}
+		if cookie.MaxAge < 0 {
+			delete(cookies, cookieKey{persisted.Name, persisted.Domain, persisted.Path})
+			continue
+		}
+		if cookie.MaxAge > 0 {
+			maxAgeExpiry := time.Now().Add(time.Duration(cookie.MaxAge) * time.Second)
+			persisted.Expires = &maxAgeExpiry
+		} else if !cookie.Expires.IsZero() {
+			expires := cookie.Expires
+			persisted.Expires = &expires
+		}
+		add(persisted)
+	}
+
+	persisted := make([]persistedCookie, 0, len(cookies))
+	for _, key := range order {
+		if cookie, ok := cookies[key]; ok {
+			persisted = append(persisted, cookie)
+		}
+	}
+
+	contents, err := json.MarshalIndent(persisted, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, contents, 0600)
+}
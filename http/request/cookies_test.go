@@ -0,0 +1,69 @@
+package request
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+func TestCookiesRoundTripThroughFile(t *testing.T) {
+	var received []*http.Cookie
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/login" {
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "abc", Path: "/", MaxAge: 3600})
+			http.SetCookie(w, &http.Cookie{Name: "admin", Value: "yes", Path: "/admin"})
+			return
+		}
+		received = r.Cookies()
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "cookies.json")
+	login := Get(server.URL + "/login")
+	response, err := login.Do()
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+	if err := login.SaveCookiesToFile(path); err != nil {
+		t.Fatal(err)
+	}
+
+	response, err = Get(server.URL + "/profile").LoadCookiesFromFile(path).Do()
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+	if len(received) != 1 || received[0].Name != "session" || received[0].Value != "abc" {
+		t.Errorf("server received cookies %v, want only session=abc", received)
+	}
+}
+
+func TestLoadCookiesKeepsDomain(t *testing.T) {
+	var received []*http.Cookie
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Cookies()
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "cookies.json")
+	contents := `[
+		{"name": "other", "value": "1", "path": "/", "domain": "other.example"},
+		{"name": "expired", "value": "3", "path": "/", "domain": "127.0.0.1", "hostOnly": true, "expires": "2000-01-01T00:00:00Z"},
+		{"name": "local", "value": "4", "path": "/", "domain": "127.0.0.1", "hostOnly": true}
+	]`
+	if err := ioutil.WriteFile(path, []byte(contents), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := Get(server.URL).LoadCookiesFromFile(path).Do()
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+	if len(received) != 1 || received[0].Name != "local" {
+		t.Errorf("server received cookies %v, want only local=4", received)
+	}
+}
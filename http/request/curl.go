@@ -0,0 +1,83 @@
+package request
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// AsCurl finalizes a copy of the request like DryRun and renders it as a curl
+// command with its method, URL, headers and body. With redact, the values of
+// credential headers and any password in the URL are replaced, so the command
+// can be logged or shared safely. Bodies set from a reader cannot be rendered,
+// since reading them would consume them.
+func (h *httpRequest) AsCurl(redact bool) (string, error) {
+	if h.streamingBody() {
+		return "", errors.New("Streaming request bodies cannot be rendered as curl")
+	}
+	if h.payload == nil && h.bodySource == nil && h.request.Body != nil {
+		return "", errors.New("Request bodies set from a reader cannot be rendered as curl")
+	}
+	c := h.copyBuilder()
+	defer func() { closeBody(c.request) }()
+	if err := c.prepare(); err != nil {
+		return "", err
+	}
+	return c.curlCommand(redact)
+}
+
+func (h *httpRequest) curlCommand(redact bool) (string, error) {
+	var body []byte
+	if h.request.GetBody != nil {
+		reader, err := h.request.GetBody()
+		if err != nil {
+			return "", err
+		}
+		body, err = ioutil.ReadAll(reader)
+		reader.Close()
+		if err != nil {
+			return "", err
+		}
+	}
+
+	header := h.request.Header
+	rawURL := h.request.URL.String()
+	if redact {
+		header = h.redactedHeader(header)
+		rawURL = redactedURL(h.request.URL)
+	}
+
+	method := h.request.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+	// curl sends GET without a body and POST with one unless told otherwise.
+	command := []string{"curl"}
+	if body == nil && method != http.MethodGet || body != nil && method != http.MethodPost {
+		command = append(command, "-X", method)
+	}
+
+	keys := make([]string, 0, len(header))
+	for key := range header {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		for _, value := range header[key] {
+			command = append(command, "-H", shellQuote(key+": "+value))
+		}
+	}
+
+	if body != nil {
+		command = append(command, "--data-binary", shellQuote(string(body)))
+	}
+	command = append(command, shellQuote(rawURL))
+	return strings.Join(command, " "), nil
+}
+
+// shellQuote quotes s for a POSIX shell.
+func shellQuote(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
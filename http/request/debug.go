@@ -0,0 +1,134 @@
+package request
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"sync"
+)
+
+// defaultDebugBodyLimit is the number of body bytes printed by SetDebug
+// unless changed with SetDebugBodyLimit.
+const defaultDebugBodyLimit = 4096
+
+// SetDebug logs every attempt and its response, headers and body, at info
+// level. Credential headers and those named with RedactHeaders are redacted,
+// and bodies are cut after the limit set with SetDebugBodyLimit.
+func (h *httpRequest) SetDebug(debug bool) *httpRequest {
+	h.debug = debug
+	return h
+}
+
+// SetDebugBodyLimit sets how many bytes of each body SetDebug prints.
+// The default is 4KB.
+func (h *httpRequest) SetDebugBodyLimit(n int) *httpRequest {
+	h.debugBodyLimit = n
+	return h
+}
+
+// RedactHeaders hides the values of the named headers wherever the request
+// is logged or rendered, in addition to Authorization and cookie headers.
+func (h *httpRequest) RedactHeaders(names ...string) *httpRequest {
+	if h.sensitiveHeaders == nil {
+		h.sensitiveHeaders = make(map[string]bool)
+	}
+	for _, name := range names {
+		h.sensitiveHeaders[http.CanonicalHeaderKey(name)] = true
+	}
+	return h
+}
+
+// dump logs each attempt and its response when SetDebug is enabled.
+func (h *httpRequest) dump(roundTrip RoundTripFunc) RoundTripFunc {
+	if !h.debug {
+		return roundTrip
+	}
+	limit := h.debugBodyLimit
+	if limit == 0 {
+		limit = defaultDebugBodyLimit
+	}
+
+	return func(request *http.Request) (*http.Response, error) {
+		redacted := request.Clone(request.Context())
+		redacted.Header = h.redactedHeader(request.Header)
+		redacted.URL.User = nil
+		// Non-nil so the dump keeps Content-Length; it is not read.
+		redacted.Body = io.NopCloser(bytes.NewReader(nil))
+		dump, err := httputil.DumpRequestOut(redacted, false)
+		if err != nil {
+			closeBody(request)
+			return nil, err
+		}
+		if request.Body != nil && request.Body != http.NoBody {
+			if h.wireBody != nil {
+				dump = appendBody(dump, h.wireBody, limit)
+			} else {
+				dump = append(dump, "[streamed body]\n"...)
+			}
+		}
+		h.logger.Info("Sending request", F("dump", string(dump)))
+
+		response, err := roundTrip(request)
+		if err != nil {
+			return response, err
+		}
+
+		safe := *response
+		safe.Header = h.redactedHeader(response.Header)
+		dump, err = httputil.DumpResponse(&safe, false)
+		if err != nil {
+			return response, nil
+		}
+		h.logger.Info("Received response", F("dump", string(dump)))
+		// The body is logged as the caller reads it, so logging never blocks
+		// on a slow or streaming body.
+		response.Body = &debugBody{ReadCloser: response.Body, limit: limit, logger: h.logger}
+		return response, nil
+	}
+}
+
+// debugBody keeps the first bytes of a response body as they are read and
+// logs them once the body is exhausted or closed.
+type debugBody struct {
+	io.ReadCloser
+	limit  int
+	prefix []byte
+	logger Logger
+	once   sync.Once
+}
+
+func (b *debugBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if room := b.limit + 1 - len(b.prefix); room > 0 {
+		if room > n {
+			room = n
+		}
+		b.prefix = append(b.prefix, p[:room]...)
+	}
+	if err != nil {
+		b.flush()
+	}
+	return n, err
+}
+
+func (b *debugBody) Close() error {
+	b.flush()
+	return b.ReadCloser.Close()
+}
+
+func (b *debugBody) flush() {
+	b.once.Do(func() {
+		b.logger.Info("Received response body", F("body", string(appendBody(nil, b.prefix, b.limit))))
+	})
+}
+
+// appendBody appends at most limit bytes of body to dump, noting how much
+// was cut.
+func appendBody(dump, body []byte, limit int) []byte {
+	if len(body) > limit {
+		return append(append(dump, body[:limit]...), fmt.Sprintf("\n[body truncated after %d bytes]\n", limit)...)
+	}
+	return append(dump, body...)
+}
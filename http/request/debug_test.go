@@ -0,0 +1,77 @@
+package request
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDebugDoesNotReadAhead(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Authorization", "secret")
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		<-release
+		w.Write([]byte("streamed body"))
+	}))
+	defer server.Close()
+	defer close(release)
+
+	logger := &recordingLogger{}
+	returned := make(chan *http.Response, 1)
+	go func() {
+		response, err := Get(server.URL).SetDebug(true).SetStreamResponse(true).SetLogger(logger).Do()
+		if err != nil {
+			t.Error(err)
+		}
+		returned <- response
+	}()
+
+	var response *http.Response
+	select {
+	case response = <-returned:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Do waited for the response body")
+	}
+	release <- struct{}{}
+	body, err := ioutil.ReadAll(response.Body)
+	response.Body.Close()
+	if err != nil || string(body) != "streamed body" {
+		t.Fatalf("body = %q, %v", body, err)
+	}
+
+	headers := logger.find("Received response")
+	if len(headers) != 1 || strings.Contains(headers[0].fields["dump"].(string), "secret") {
+		t.Errorf("response headers logged as %v", headers)
+	}
+	bodies := logger.find("Received response body")
+	if len(bodies) != 1 || bodies[0].fields["body"] != "streamed body" {
+		t.Errorf("response body logged as %v", bodies)
+	}
+}
+
+func TestDebugBodyLimit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("0123456789"))
+	}))
+	defer server.Close()
+
+	logger := &recordingLogger{}
+	response, err := Get(server.URL).SetDebug(true).SetDebugBodyLimit(4).SetLogger(logger).Do()
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := ioutil.ReadAll(response.Body)
+	response.Body.Close()
+	if string(body) != "0123456789" {
+		t.Errorf("body = %q, want the full body", body)
+	}
+	bodies := logger.find("Received response body")
+	if len(bodies) != 1 || bodies[0].fields["body"] != "0123\n[body truncated after 4 bytes]\n" {
+		t.Errorf("response body logged as %v", bodies)
+	}
+}
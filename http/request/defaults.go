@@ -0,0 +1,35 @@
+package request
+
+import (
+	"net/url"
+	"time"
+)
+
+// Defaults are the package-wide settings every new request starts with. Its
+// env tags let it be loaded with the config package:
+//
+//	var defaults request.Defaults
+//	if err := config.Load(&defaults); err != nil {
+//		return err
+//	}
+//	request.SetDefaults(defaults)
+type Defaults struct {
+	// Timeout bounds each attempt. Zero means no limit.
+	Timeout time.Duration `env:"HTTP_CLIENT_TIMEOUT" default:"30s"`
+	// Retries is how many times a failed attempt is retried.
+	Retries uint8 `env:"HTTP_CLIENT_RETRIES"`
+	// Proxy routes requests using the shared transport through a proxy. Nil
+	// honors the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables.
+	Proxy *url.URL `env:"HTTP_CLIENT_PROXY"`
+}
+
+var defaults = Defaults{Timeout: 30 * time.Second}
+
+// SetDefaults replaces the settings new requests start with. It must be
+// called before requests are made, typically during program initialization.
+func SetDefaults(d Defaults) {
+	defaults = d
+	transports.reset(func() {
+		transports.proxy = d.Proxy
+	})
+}
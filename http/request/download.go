@@ -0,0 +1,114 @@
+package request
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+)
+
+// SetStreamResponse returns the response body unread even when retries are
+// enabled. Retries then only cover failures before the response arrives.
+func (h *httpRequest) SetStreamResponse(stream bool) *httpRequest {
+	h.streamResponse = stream
+	return h
+}
+
+type progressWriter struct {
+	written  int64
+	total    int64
+	progress func(written, total int64)
+}
+
+func (w *progressWriter) Write(p []byte) (int, error) {
+	w.written += int64(len(p))
+	w.progress(w.written, w.total)
+	return len(p), nil
+}
+
+// DownloadToFile streams the response body into the file at path. If progress
+// is not nil it is called after every write with the bytes written so far and
+// the expected total, which is -1 when the server does not send a length. The
+// file is only replaced once the download completes. Non-2xx responses are
+// returned as a *StatusError.
+//
+// The attempt timeout does not bound the whole download, which may take
+// arbitrarily long. It bounds waiting for the response headers, unless
+// SetHeadersTimeout is set, and each stall of the body: a download that
+// receives nothing for that long fails with a *TimeoutError. Use the request
+// context or SetTotalDeadline to bound the total time.
+func (h *httpRequest) DownloadToFile(path string, progress func(written, total int64)) error {
+	timeout, streamResponse, headersTimeout := h.timeout, h.streamResponse, h.transportOptions.headersTimeout
+	h.timeout, h.streamResponse = 0, true
+	if headersTimeout == 0 {
+		h.transportOptions.headersTimeout = timeout
+	}
+	parent := h.request.Context()
+	ctx, cancel := context.WithCancel(parent)
+	defer cancel()
+	h.request = h.request.WithContext(ctx)
+	defer func() {
+		h.timeout, h.streamResponse, h.transportOptions.headersTimeout = timeout, streamResponse, headersTimeout
+		h.request = h.request.WithContext(parent)
+	}()
+
+	response, err := h.Do()
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if !isSuccess(response.StatusCode) {
+		return newStatusError(response)
+	}
+
+	file, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".*.part")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	var writer io.Writer = file
+	if progress != nil {
+		writer = io.MultiWriter(file, &progressWriter{total: response.ContentLength, progress: progress})
+	}
+	var body io.Reader = response.Body
+	var stalled int32
+	if timeout > 0 {
+		timer := time.AfterFunc(timeout, func() {
+			atomic.StoreInt32(&stalled, 1)
+			cancel()
+		})
+		defer timer.Stop()
+		body = idleTimeoutReader{Reader: body, timer: timer, timeout: timeout}
+	}
+	if _, err = io.Copy(writer, body); err != nil {
+		file.Close()
+		if atomic.LoadInt32(&stalled) == 1 {
+			return &TimeoutError{Err: fmt.Errorf("Download stalled for %v: %w", timeout, err)}
+		}
+		return err
+	}
+	if err = file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), path)
+}
+
+// idleTimeoutReader restarts timer whenever a read returns, so it only fires
+// once the body stalls for timeout.
+type idleTimeoutReader struct {
+	io.Reader
+	timer   *time.Timer
+	timeout time.Duration
+}
+
+func (r idleTimeoutReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	r.timer.Reset(r.timeout)
+	return n, err
+}
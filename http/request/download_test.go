@@ -0,0 +1,62 @@
+package request
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDownloadOutlastsTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for i := 0; i < 8; i++ {
+			w.Write([]byte("chunk\n"))
+			w.(http.Flusher).Flush()
+			time.Sleep(50 * time.Millisecond)
+		}
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "download")
+	var written int64
+	err := Get(server.URL).SetTimeout(200*time.Millisecond).DownloadToFile(path, func(n, _ int64) { written = n })
+	if err != nil {
+		t.Fatal(err)
+	}
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := strings.Repeat("chunk\n", 8); string(contents) != want || written != int64(len(want)) {
+		t.Errorf("downloaded %q, reported %d bytes", contents, written)
+	}
+}
+
+func TestDownloadStalls(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("chunk\n"))
+		w.(http.Flusher).Flush()
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	path := filepath.Join(t.TempDir(), "download")
+	err := Get(server.URL).SetTimeout(100*time.Millisecond).DownloadToFile(path, nil)
+	var timeout *TimeoutError
+	if !errors.As(err, &timeout) {
+		t.Fatalf("err = %v, want a *TimeoutError", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("stalled download replaced the file")
+	}
+}
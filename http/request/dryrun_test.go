@@ -0,0 +1,68 @@
+package request
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+// trackedSource is a rewindable payload counting the readers it opened and
+// closed.
+type trackedSource struct {
+	opened, closed int
+}
+
+func (s *trackedSource) open() (io.ReadCloser, error) {
+	s.opened++
+	return &sourceReader{Reader: strings.NewReader("payload"), source: s}, nil
+}
+
+type sourceReader struct {
+	io.Reader
+	source *trackedSource
+}
+
+func (r *sourceReader) Close() error {
+	r.source.closed++
+	return nil
+}
+
+func TestDryRun(t *testing.T) {
+	if err := Get("http://unreachable.invalid/users").SetHeader("Accept", "application/json").DryRun(); err != nil {
+		t.Errorf("valid request failed DryRun: %v", err)
+	}
+	if err := Get("").DryRun(); err != ErrMissingURL {
+		t.Errorf("request without URL: err = %v, want ErrMissingURL", err)
+	}
+	if err := Get("http://example.com").SetMethod("FETCH").DryRun(); err == nil {
+		t.Error("request with an invalid method passed DryRun")
+	}
+	if err := Post("http://example.com").SetMaxPayloadSize(4).SetPayload([]byte("too large")).DryRun(); err == nil {
+		t.Error("oversized payload passed DryRun")
+	}
+}
+
+func TestDryRunLeavesRequestUnchanged(t *testing.T) {
+	source := &trackedSource{}
+	builder := Post("http://example.com").SetRetries(2).SetRewindablePayload(source.open, 7)
+	if err := builder.DryRun(); err != nil {
+		t.Fatal(err)
+	}
+	if source.opened != source.closed {
+		t.Errorf("opened %d payload readers but closed %d", source.opened, source.closed)
+	}
+	if key := builder.request.Header.Get(idempotencyKeyHeader); key != "" {
+		t.Errorf("DryRun stored idempotency key %q", key)
+	}
+
+	reader := ioutil.NopCloser(bytes.NewReader([]byte("streamed")))
+	builder = Post("http://example.com").SetPayloadFromReader(reader)
+	if err := builder.DryRun(); err != nil {
+		t.Fatal(err)
+	}
+	if builder.payload != nil || builder.request.Body == nil {
+		t.Error("DryRun buffered the reader body")
+	}
+}
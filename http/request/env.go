@@ -0,0 +1,40 @@
+package request
+
+import (
+	"fmt"
+	"os"
+)
+
+type envHeader struct {
+	key      string
+	envVar   string
+	required bool
+}
+
+// SetHeaderFromEnv sets header key from environment variable envVar when the
+// request is sent. Do fails if the variable is unset.
+func (h *httpRequest) SetHeaderFromEnv(key, envVar string) *httpRequest {
+	h.envHeaders = append(h.envHeaders, envHeader{key: key, envVar: envVar, required: true})
+	return h
+}
+
+// SetOptionalHeaderFromEnv is like SetHeaderFromEnv but leaves the header
+// unset instead of failing when the variable is unset.
+func (h *httpRequest) SetOptionalHeaderFromEnv(key, envVar string) *httpRequest {
+	h.envHeaders = append(h.envHeaders, envHeader{key: key, envVar: envVar})
+	return h
+}
+
+func (h *httpRequest) resolveEnvHeaders() error {
+	for _, header := range h.envHeaders {
+		value, ok := os.LookupEnv(header.envVar)
+		if !ok {
+			if header.required {
+				return fmt.Errorf("%w: %s for header %s", ErrMissingEnv, header.envVar, header.key)
+			}
+			continue
+		}
+		h.SetHeader(header.key, value)
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package request
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSetHeaderFromEnv(t *testing.T) {
+	var token, region string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, region = r.Header.Get("X-Token"), r.Header.Get("X-Region")
+	}))
+	defer server.Close()
+
+	builder := Get(server.URL).
+		SetHeaderFromEnv("X-Token", "REQUEST_TEST_TOKEN").
+		SetOptionalHeaderFromEnv("X-Region", "REQUEST_TEST_REGION")
+	t.Setenv("REQUEST_TEST_TOKEN", "secret")
+	response, err := builder.Do()
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+	if token != "secret" || region != "" {
+		t.Errorf("server received X-Token %q and X-Region %q", token, region)
+	}
+}
+
+func TestSetHeaderFromEnvMissing(t *testing.T) {
+	_, err := Get("http://example.com").SetHeaderFromEnv("X-Token", "REQUEST_TEST_UNSET").Do()
+	if !errors.Is(err, ErrMissingEnv) {
+		t.Errorf("err = %v, want ErrMissingEnv", err)
+	}
+}
@@ -0,0 +1,107 @@
+package request
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+)
+
+var (
+	// ErrMissingURL is returned when a request is sent without a URI.
+	ErrMissingURL = errors.New("Request URI must be specified")
+	// ErrPayloadTooLarge is returned when the payload exceeds the size set
+	// with SetMaxPayloadSize.
+	ErrPayloadTooLarge = errors.New("Request payload is too large")
+	// ErrMissingEnv is returned when a variable required by SetHeaderFromEnv
+	// is not set.
+	ErrMissingEnv = errors.New("Environment variable is not set")
+	// ErrETagNotObserved is returned when ExpectETag gives up waiting.
+	ErrETagNotObserved = errors.New("Expected ETag not observed")
+	// ErrMaxRetriesExceeded matches every *RetryError through errors.Is.
+	ErrMaxRetriesExceeded = errors.New("Request failed after exhausting retries")
+	// ErrCircuitOpen is returned without sending the request while the
+	// circuit breaker for its host is open.
+	ErrCircuitOpen = errors.New("Circuit breaker is open")
+	// ErrRetryBudgetExhausted matches every *RetryBudgetError through
+	// errors.Is.
+	ErrRetryBudgetExhausted = errors.New("Retry budget exhausted")
+	// ErrShutdown is returned for requests sent after Shutdown, or after
+	// Client.Shutdown for requests made through that Client.
+	ErrShutdown = errors.New("Requests have been shut down")
+)
+
+// RetryError is returned when every attempt allowed by SetRetries failed.
+type RetryError struct {
+	// Attempts is the number of times the request was sent.
+	Attempts int
+	// StatusCode is the status of the last response, or 0 if the last
+	// attempt failed without a response.
+	StatusCode int
+	// Err is the error of the last attempt, if it had one.
+	Err error
+}
+
+func (e *RetryError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("Request failed after %d attempts: %v", e.Attempts, e.Err)
+	}
+	return fmt.Sprintf("Request failed after %d attempts with status %d", e.Attempts, e.StatusCode)
+}
+
+func (e *RetryError) Is(target error) bool { return target == ErrMaxRetriesExceeded }
+
+func (e *RetryError) Unwrap() error { return e.Err }
+
+// RetryBudgetError is returned when an attempt failed and could have been
+// retried, but the retry budget set with SetRetryBudget was spent.
+type RetryBudgetError struct {
+	// Attempts is the number of times the request was sent.
+	Attempts int
+	// StatusCode is the status of the last response, or 0 if the last
+	// attempt failed without a response.
+	StatusCode int
+	// Err is the error of the last attempt, if it had one.
+	Err error
+}
+
+func (e *RetryBudgetError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("Retry budget exhausted after %d attempts: %v", e.Attempts, e.Err)
+	}
+	return fmt.Sprintf("Retry budget exhausted after %d attempts with status %d", e.Attempts, e.StatusCode)
+}
+
+func (e *RetryBudgetError) Is(target error) bool { return target == ErrRetryBudgetExhausted }
+
+func (e *RetryBudgetError) Unwrap() error { return e.Err }
+
+// TimeoutError is returned when an attempt times out. It satisfies net.Error.
+type TimeoutError struct {
+	Err error
+}
+
+func (e *TimeoutError) Error() string { return "Request timed out: " + e.Err.Error() }
+
+func (e *TimeoutError) Unwrap() error { return e.Err }
+
+func (e *TimeoutError) Timeout() bool { return true }
+
+func (e *TimeoutError) Temporary() bool { return true }
+
+// StatusError is returned by the decoding helpers when the response status
+// code is not 2xx. Body holds the response body.
+type StatusError struct {
+	StatusCode int
+	Status     string
+	Body       []byte
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("Unexpected response status %s", e.Status)
+}
+
+func newStatusError(response *http.Response) error {
+	body, _ := ioutil.ReadAll(response.Body)
+	return &StatusError{StatusCode: response.StatusCode, Status: response.Status, Body: body}
+}
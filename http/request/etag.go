@@ -0,0 +1,58 @@
+package request
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ExpectETag makes Do repeat the request, backing off between attempts, until
+// the response ETag matches etag or maxWait elapses. Useful for read-after-write
+// consistency against eventually consistent stores. The delay between attempts
+// is the one set with SetBackoff, or otherwise starts at 50ms and doubles.
+func (h *httpRequest) ExpectETag(etag string, maxWait time.Duration) *httpRequest {
+	h.expectedETag = etag
+	h.etagMaxWait = maxWait
+	return h
+}
+
+func (h *httpRequest) doExpectingETag() (*http.Response, error) {
+	deadline := time.Now().Add(h.etagMaxWait)
+	backoff := h.backoff
+	if backoff == nil {
+		backoff = ExponentialBackoff(50*time.Millisecond, h.etagMaxWait)
+	}
+	for attempt := 1; ; attempt++ {
+		response, err := h.roundTrip()
+		if err != nil {
+			return response, err
+		}
+		if sameETag(response.Header.Get("ETag"), h.expectedETag) {
+			return response, nil
+		}
+		discardResponse(response)
+
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return nil, fmt.Errorf("%w: %s within %s", ErrETagNotObserved, h.expectedETag, h.etagMaxWait)
+		}
+		delay := backoff(attempt)
+		if delay > remaining {
+			delay = remaining
+		}
+
+		select {
+		case <-h.request.Context().Done():
+			return nil, h.request.Context().Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+func sameETag(actual, expected string) bool {
+	normalize := func(etag string) string {
+		return strings.Trim(strings.TrimPrefix(etag, "W/"), `"`)
+	}
+	return actual != "" && normalize(actual) == normalize(expected)
+}
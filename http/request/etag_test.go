@@ -0,0 +1,67 @@
+package request
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// etagServer serves a stale ETag for the first stale requests and "v2" after.
+func etagServer(calls *int32, stale int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(calls, 1) <= stale {
+			w.Header().Set("ETag", `"v1"`)
+			return
+		}
+		w.Header().Set("ETag", `"v2"`)
+	}))
+}
+
+func TestExpectETag(t *testing.T) {
+	var calls int32
+	server := etagServer(&calls, 2)
+	defer server.Close()
+
+	response, err := Get(server.URL).ExpectETag("v2", 5*time.Second).Do()
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+	if response.Header.Get("ETag") != `"v2"` || calls != 3 {
+		t.Errorf("got ETag %s after %d calls, want \"v2\" after 3", response.Header.Get("ETag"), calls)
+	}
+}
+
+func TestExpectETagUsesBackoff(t *testing.T) {
+	var calls int32
+	server := etagServer(&calls, 3)
+	defer server.Close()
+
+	var retries []int
+	backoff := func(attempt int) time.Duration {
+		retries = append(retries, attempt)
+		return time.Millisecond
+	}
+	response, err := Get(server.URL).SetBackoff(backoff).ExpectETag("v2", 5*time.Second).Do()
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+	if len(retries) != 3 || retries[0] != 1 || retries[2] != 3 {
+		t.Errorf("backoff called for attempts %v, want [1 2 3]", retries)
+	}
+}
+
+func TestExpectETagGivesUp(t *testing.T) {
+	var calls int32
+	server := etagServer(&calls, 1000)
+	defer server.Close()
+
+	_, err := Get(server.URL).ExpectETag("v2", 100*time.Millisecond).Do()
+	if !errors.Is(err, ErrETagNotObserved) {
+		t.Errorf("err = %v, want ErrETagNotObserved", err)
+	}
+}
@@ -0,0 +1,34 @@
+package request
+
+import "fmt"
+
+// DoJSON sends the request and decodes the JSON response body into a value
+// of type T. The wrapped response is returned alongside, including for
+// non-2xx responses, which are reported as a *StatusError.
+func DoJSON[T any](h *httpRequest) (T, *Response, error) {
+	return doDecoded[T](h, "application/json", "JSON", (*Response).JSON)
+}
+
+// DoXML is like DoJSON for XML responses.
+func DoXML[T any](h *httpRequest) (T, *Response, error) {
+	return doDecoded[T](h, "application/xml", "XML", (*Response).XML)
+}
+
+func doDecoded[T any](h *httpRequest, accept, format string, decode func(*Response, interface{}) error) (T, *Response, error) {
+	var value T
+	if h.request.Header.Get("Accept") == "" {
+		h.SetHeader("Accept", accept)
+	}
+
+	response, err := h.DoWrapped()
+	if err != nil {
+		return value, nil, err
+	}
+	if !response.IsSuccess() {
+		return value, response, response.statusError()
+	}
+	if err = decode(response, &value); err != nil {
+		return value, response, fmt.Errorf("Could not decode %s response: %w", format, err)
+	}
+	return value, response, nil
+}
@@ -0,0 +1,84 @@
+package request
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// GraphQLLocation is a position in the query that a GraphQLError refers to.
+type GraphQLLocation struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// GraphQLError is an entry of the errors list of a GraphQL response.
+type GraphQLError struct {
+	Message    string                 `json:"message"`
+	Locations  []GraphQLLocation      `json:"locations,omitempty"`
+	Path       []interface{}          `json:"path,omitempty"`
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
+}
+
+func (e GraphQLError) Error() string { return e.Message }
+
+// GraphQLErrors is returned by DoGraphQL when the response reports errors.
+type GraphQLErrors []GraphQLError
+
+func (e GraphQLErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Message
+	}
+	return "GraphQL request failed: " + strings.Join(messages, "; ")
+}
+
+// GraphQL turns the request into a POST of query and variables, which may be
+// nil, in the standard JSON encoding. Send it with DoGraphQL.
+func (h *httpRequest) GraphQL(query string, variables map[string]interface{}) *httpRequest {
+	h.request.Method = http.MethodPost
+	return h.SetJSONPayload(struct {
+		Query     string                 `json:"query"`
+		Variables map[string]interface{} `json:"variables,omitempty"`
+	}{query, variables})
+}
+
+// DoGraphQL performs the request and decodes the data field of the response
+// into data. Errors listed in the response are returned as GraphQLErrors,
+// after decoding any partial data. Non-2xx responses without a GraphQL body
+// are returned as a *StatusError.
+func (h *httpRequest) DoGraphQL(data interface{}) error {
+	if h.request.Header.Get("Accept") == "" {
+		h.SetHeader("Accept", "application/json")
+	}
+
+	response, err := h.DoWrapped()
+	if err != nil {
+		return err
+	}
+
+	var envelope struct {
+		Data   json.RawMessage `json:"data"`
+		Errors GraphQLErrors   `json:"errors"`
+	}
+	if err = response.JSON(&envelope); err != nil {
+		if !response.IsSuccess() {
+			return response.statusError()
+		}
+		return fmt.Errorf("Could not decode GraphQL response: %w", err)
+	}
+
+	if len(envelope.Data) > 0 && string(envelope.Data) != "null" && data != nil {
+		if err = json.Unmarshal(envelope.Data, data); err != nil {
+			return fmt.Errorf("Could not decode GraphQL data: %w", err)
+		}
+	}
+	if len(envelope.Errors) > 0 {
+		return envelope.Errors
+	}
+	if !response.IsSuccess() {
+		return response.statusError()
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package request
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// SetHedging sends a second, identical attempt if the first has not
+// responded within delay, and uses whichever response arrives first,
+// canceling the other. The server may receive both, so hedging only applies
+// to GET and HEAD requests.
+func (h *httpRequest) SetHedging(delay time.Duration) *httpRequest {
+	h.hedgeDelay = delay
+	return h
+}
+
+type hedgedResult struct {
+	attempt  int
+	response *http.Response
+	err      error
+	cancel   context.CancelFunc
+}
+
+func (h *httpRequest) hedge(roundTrip RoundTripFunc) RoundTripFunc {
+	method := h.request.Method
+	if h.hedgeDelay <= 0 || method != http.MethodGet && method != http.MethodHead {
+		return roundTrip
+	}
+	delay := h.hedgeDelay
+
+	return func(request *http.Request) (*http.Response, error) {
+		results := make(chan hedgedResult, 2)
+		var cancels []context.CancelFunc
+		launch := func() {
+			ctx, cancel := context.WithCancel(request.Context())
+			attempt := len(cancels)
+			cancels = append(cancels, cancel)
+			go func() {
+				response, err := roundTrip(request.WithContext(ctx))
+				results <- hedgedResult{attempt, response, err, cancel}
+			}()
+		}
+
+		launch()
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		hedgeTimer := timer.C
+		pending := 1
+		for {
+			select {
+			case <-hedgeTimer:
+				hedgeTimer = nil
+				h.logger.Debug("Sending hedged attempt", F("url", redactedURL(request.URL)), F("delay", delay))
+				launch()
+				pending++
+			case result := <-results:
+				pending--
+				if result.err != nil {
+					result.cancel()
+					if pending > 0 {
+						continue
+					}
+					return nil, result.err
+				}
+				for attempt, cancel := range cancels {
+					if attempt != result.attempt {
+						cancel()
+					}
+				}
+				if pending > 0 {
+					go discardHedged(results)
+				}
+				result.response.Body = cancelOnClose{result.response.Body, result.cancel}
+				return result.response, nil
+			}
+		}
+	}
+}
+
+// discardHedged releases the losing attempt once it completes.
+func discardHedged(results <-chan hedgedResult) {
+	result := <-results
+	if result.err == nil {
+		discardResponse(result.response)
+	}
+	result.cancel()
+}
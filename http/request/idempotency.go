@@ -0,0 +1,48 @@
+package request
+
+import (
+	"crypto/rand"
+	"fmt"
+	"net/http"
+)
+
+const idempotencyKeyHeader = "Idempotency-Key"
+
+// SetIdempotencyKey sends key in the Idempotency-Key header so the server can
+// recognize retries of the same operation. Without it, a random key is
+// generated for POST and PATCH requests that have retries enabled.
+func (h *httpRequest) SetIdempotencyKey(key string) *httpRequest {
+	return h.SetHeader(idempotencyKeyHeader, key)
+}
+
+// ensureIdempotencyKey attaches a generated key to a retried POST or PATCH
+// request that has none. The key belongs to the current call: every attempt
+// of the call carries it, and the returned function, called once the call is
+// done, removes it so the next call generates its own.
+func (h *httpRequest) ensureIdempotencyKey() (func(), error) {
+	if h.retries == 0 || h.request.Header.Get(idempotencyKeyHeader) != "" {
+		return func() {}, nil
+	}
+	if h.request.Method != http.MethodPost && h.request.Method != http.MethodPatch {
+		return func() {}, nil
+	}
+	key, err := newUUID()
+	if err != nil {
+		return nil, fmt.Errorf("Could not generate idempotency key: %w", err)
+	}
+	header := h.request.Header
+	h.request.Header = header.Clone()
+	h.request.Header.Set(idempotencyKeyHeader, key)
+	return func() { h.request.Header = header }, nil
+}
+
+// newUUID returns a random version 4 UUID.
+func newUUID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
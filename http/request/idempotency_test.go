@@ -0,0 +1,128 @@
+package request
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+type logEntry struct {
+	level  string
+	msg    string
+	fields map[string]interface{}
+}
+
+// recordingLogger keeps every entry logged through it.
+type recordingLogger struct {
+	mu      sync.Mutex
+	entries []logEntry
+}
+
+func (l *recordingLogger) log(level, msg string, fields []Field) {
+	entry := logEntry{level: level, msg: msg, fields: make(map[string]interface{}, len(fields))}
+	for _, field := range fields {
+		entry.fields[field.Key] = field.Value
+	}
+	l.mu.Lock()
+	l.entries = append(l.entries, entry)
+	l.mu.Unlock()
+}
+
+func (l *recordingLogger) Debug(msg string, fields ...Field) { l.log("DEBUG", msg, fields) }
+func (l *recordingLogger) Info(msg string, fields ...Field)  { l.log("INFO", msg, fields) }
+func (l *recordingLogger) Error(msg string, fields ...Field) { l.log("ERROR", msg, fields) }
+
+func (l *recordingLogger) find(msg string) []logEntry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var found []logEntry
+	for _, entry := range l.entries {
+		if entry.msg == msg {
+			found = append(found, entry)
+		}
+	}
+	return found
+}
+
+func TestIdempotencyKeyLoggedPerRetry(t *testing.T) {
+	var keys []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys = append(keys, r.Header.Get(idempotencyKeyHeader))
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	logger := &recordingLogger{}
+	_, err := Post(server.URL).SetPayload([]byte("charge")).SetRetries(2).
+		SetRetryPolicy(RetryOnStatus(http.StatusServiceUnavailable)).
+		SetBackoff(ConstantBackoff(0)).SetLogger(logger).Do()
+	if err == nil {
+		t.Fatal("request against a failing server succeeded")
+	}
+
+	if len(keys) != 3 || keys[0] == "" || keys[1] != keys[0] || keys[2] != keys[0] {
+		t.Fatalf("server saw keys %q, want 3 equal generated keys", keys)
+	}
+	entries := logger.find("Retrying idempotent request")
+	if len(entries) != 2 {
+		t.Fatalf("logged %d retries, want 2", len(entries))
+	}
+	for i, entry := range entries {
+		if entry.level != "DEBUG" {
+			t.Errorf("retry %d logged at %s, want DEBUG", i+1, entry.level)
+		}
+		if entry.fields["idempotency_key"] != keys[0] {
+			t.Errorf("retry %d logged key %v, want %s", i+1, entry.fields["idempotency_key"], keys[0])
+		}
+		if entry.fields["attempt"] != i+2 {
+			t.Errorf("retry %d logged attempt %v, want %d", i+1, entry.fields["attempt"], i+2)
+		}
+		if _, ok := entry.fields["delay"]; !ok {
+			t.Errorf("retry %d logged no delay", i+1)
+		}
+	}
+}
+
+func TestIdempotencyKeyPerCall(t *testing.T) {
+	var keys []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys = append(keys, r.Header.Get(idempotencyKeyHeader))
+	}))
+	defer server.Close()
+
+	builder := Post(server.URL).SetPayload([]byte("charge")).SetRetries(1)
+	for i := 0; i < 2; i++ {
+		response, err := builder.Do()
+		if err != nil {
+			t.Fatal(err)
+		}
+		response.Body.Close()
+	}
+	if len(keys) != 2 || keys[0] == "" || keys[1] == "" || keys[0] == keys[1] {
+		t.Errorf("server saw keys %q, want a new key per call", keys)
+	}
+	if key := builder.request.Header.Get(idempotencyKeyHeader); key != "" {
+		t.Errorf("builder kept the generated key %q", key)
+	}
+}
+
+func TestIdempotencyKeySetByCaller(t *testing.T) {
+	var keys []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys = append(keys, r.Header.Get(idempotencyKeyHeader))
+	}))
+	defer server.Close()
+
+	builder := Post(server.URL).SetPayload([]byte("charge")).SetRetries(1).SetIdempotencyKey("order-42")
+	for i := 0; i < 2; i++ {
+		response, err := builder.Do()
+		if err != nil {
+			t.Fatal(err)
+		}
+		response.Body.Close()
+	}
+	if len(keys) != 2 || keys[0] != "order-42" || keys[1] != "order-42" {
+		t.Errorf("server saw keys %q, want the caller's key", keys)
+	}
+}
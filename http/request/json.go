@@ -0,0 +1,42 @@
+package request
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"go.uber.org/multierr"
+)
+
+func isSuccess(statusCode int) bool {
+	return statusCode >= 200 && statusCode < 300
+}
+
+// SetJSONPayload sets the JSON encoding of v as the payload along with a
+// Content-Type of application/json. Encoding errors are reported by Do.
+func (h *httpRequest) SetJSONPayload(v interface{}) *httpRequest {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		h.err = multierr.Append(h.err, fmt.Errorf("Could not encode JSON payload: %v", err))
+		return h
+	}
+	return h.SetHeader("Content-Type", "application/json").SetPayload(payload)
+}
+
+// DoAndDecode performs the request and decodes the JSON response body into
+// target. Non-2xx responses are returned as a *StatusError.
+func (h *httpRequest) DoAndDecode(target interface{}) error {
+	if h.request.Header.Get("Accept") == "" {
+		h.SetHeader("Accept", "application/json")
+	}
+
+	response, err := h.Do()
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if !isSuccess(response.StatusCode) {
+		return newStatusError(response)
+	}
+	return json.NewDecoder(response.Body).Decode(target)
+}
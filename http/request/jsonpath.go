@@ -0,0 +1,71 @@
+package request
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DoJSONPath performs the request, decodes the JSON response body and returns
+// the value found at path. Paths use dots for object keys and brackets for
+// array indices, e.g. "data.items[0].id". Non-2xx responses are returned as a
+// *StatusError.
+func (h *httpRequest) DoJSONPath(path string) (interface{}, error) {
+	response, err := h.Do()
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	if !isSuccess(response.StatusCode) {
+		return nil, newStatusError(response)
+	}
+
+	var document interface{}
+	if err = json.NewDecoder(response.Body).Decode(&document); err != nil {
+		return nil, err
+	}
+	return lookupJSONPath(document, path)
+}
+
+func lookupJSONPath(document interface{}, path string) (interface{}, error) {
+	value := document
+	for _, segment := range strings.Split(path, ".") {
+		key := segment
+		var indices []string
+		if bracket := strings.Index(segment, "["); bracket >= 0 {
+			key = segment[:bracket]
+			if !strings.HasSuffix(segment, "]") {
+				return nil, fmt.Errorf("Invalid JSON path segment %q", segment)
+			}
+			indices = strings.Split(segment[bracket+1:len(segment)-1], "][")
+		}
+
+		if key != "" {
+			object, ok := value.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("JSON path %q: %q is not an object", path, key)
+			}
+			if value, ok = object[key]; !ok {
+				return nil, fmt.Errorf("JSON path %q: key %q not found", path, key)
+			}
+		}
+
+		for _, index := range indices {
+			i, err := strconv.Atoi(index)
+			if err != nil {
+				return nil, fmt.Errorf("JSON path %q: invalid index %q", path, index)
+			}
+			array, ok := value.([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("JSON path %q: value at %q is not an array", path, segment)
+			}
+			if i < 0 || i >= len(array) {
+				return nil, fmt.Errorf("JSON path %q: index %d out of range", path, i)
+			}
+			value = array[i]
+		}
+	}
+	return value, nil
+}
@@ -0,0 +1,49 @@
+package request
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func jsonServer(status int, body string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+}
+
+func TestDoJSONPath(t *testing.T) {
+	server := jsonServer(http.StatusOK, `{"data": {"items": [{"id": "a"}, {"id": "b"}]}}`)
+	defer server.Close()
+
+	value, err := Get(server.URL).DoJSONPath("data.items[1].id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value != "b" {
+		t.Errorf("DoJSONPath = %v, want b", value)
+	}
+
+	for _, path := range []string{"data.missing", "data.items[2].id", "data.items.id"} {
+		if _, err := Get(server.URL).DoJSONPath(path); err == nil {
+			t.Errorf("DoJSONPath(%q) found a value", path)
+		}
+	}
+}
+
+func TestDoJSONPathStatusError(t *testing.T) {
+	server := jsonServer(http.StatusNotFound, `{"data": {"error": "not found"}}`)
+	defer server.Close()
+
+	value, err := Get(server.URL).DoJSONPath("data.error")
+	var status *StatusError
+	if !errors.As(err, &status) || status.StatusCode != http.StatusNotFound {
+		t.Fatalf("DoJSONPath = %v, %v, want a 404 *StatusError", value, err)
+	}
+	if string(status.Body) != `{"data": {"error": "not found"}}` {
+		t.Errorf("StatusError body = %q", status.Body)
+	}
+}
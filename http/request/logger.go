@@ -0,0 +1,119 @@
+package request
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+)
+
+// Field is a key/value pair attached to a log message.
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// F returns a Field.
+func F(key string, value interface{}) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Logger is the structured logger used for all of the package's logging.
+// Adapters for zap and logrus live in the zaplogger and logruslogger
+// packages.
+type Logger interface {
+	Debug(msg string, fields ...Field)
+	Info(msg string, fields ...Field)
+	Error(msg string, fields ...Field)
+}
+
+// StdLogger adapts a *log.Logger to Logger, writing messages as
+// "[LEVEL] msg key=value ...". A nil Logger writes to the standard logger of
+// the log package. Debug messages are dropped unless Verbose is set.
+type StdLogger struct {
+	Logger  *log.Logger
+	Verbose bool
+}
+
+func (l StdLogger) Debug(msg string, fields ...Field) {
+	if l.Verbose {
+		l.output("DEBUG", msg, fields)
+	}
+}
+
+func (l StdLogger) Info(msg string, fields ...Field) {
+	l.output("INFO", msg, fields)
+}
+
+func (l StdLogger) Error(msg string, fields ...Field) {
+	l.output("ERROR", msg, fields)
+}
+
+func (l StdLogger) output(level, msg string, fields []Field) {
+	var line strings.Builder
+	fmt.Fprintf(&line, "[%s] %s", level, msg)
+	for _, field := range fields {
+		fmt.Fprintf(&line, " %s=%v", field.Key, field.Value)
+	}
+	if l.Logger == nil {
+		log.Print(line.String())
+		return
+	}
+	l.Logger.Print(line.String())
+}
+
+// SetLogger routes the request's logging through logger.
+func (h *httpRequest) SetLogger(logger Logger) *httpRequest {
+	h.logger = logger
+	if h.tags != nil {
+		h.logger = newTaggedLogger(logger, h.tags)
+	}
+	return h
+}
+
+// taggedLogger adds the tags set with SetTag to every entry.
+type taggedLogger struct {
+	Logger
+	tags []Field
+}
+
+func newTaggedLogger(logger Logger, tags map[string]string) taggedLogger {
+	if tagged, ok := logger.(taggedLogger); ok {
+		logger = tagged.Logger
+	}
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	fields := make([]Field, len(keys))
+	for i, key := range keys {
+		fields[i] = F(key, tags[key])
+	}
+	return taggedLogger{Logger: logger, tags: fields}
+}
+
+// debugEnabled reports whether logger writes Debug messages, so that callers
+// can skip building the fields of messages that would be dropped. Loggers
+// other than StdLogger are assumed to write them.
+func debugEnabled(logger Logger) bool {
+	switch l := logger.(type) {
+	case StdLogger:
+		return l.Verbose
+	case taggedLogger:
+		return debugEnabled(l.Logger)
+	}
+	return true
+}
+
+func (l taggedLogger) Debug(msg string, fields ...Field) {
+	l.Logger.Debug(msg, append(fields[:len(fields):len(fields)], l.tags...)...)
+}
+
+func (l taggedLogger) Info(msg string, fields ...Field) {
+	l.Logger.Info(msg, append(fields[:len(fields):len(fields)], l.tags...)...)
+}
+
+func (l taggedLogger) Error(msg string, fields ...Field) {
+	l.Logger.Error(msg, append(fields[:len(fields):len(fields)], l.tags...)...)
+}
@@ -0,0 +1,37 @@
+// Package logruslogger adapts a logrus logger to the request package's Logger.
+package logruslogger
+
+import (
+	"github.com/metamemelord/go-utilities/http/request"
+	"github.com/sirupsen/logrus"
+)
+
+type logger struct {
+	logger logrus.FieldLogger
+}
+
+// New returns a request.Logger that writes to l, which may be a
+// *logrus.Logger or a *logrus.Entry.
+func New(l logrus.FieldLogger) request.Logger {
+	return logger{logger: l}
+}
+
+func (l logger) Debug(msg string, fields ...request.Field) {
+	l.logger.WithFields(logrusFields(fields)).Debug(msg)
+}
+
+func (l logger) Info(msg string, fields ...request.Field) {
+	l.logger.WithFields(logrusFields(fields)).Info(msg)
+}
+
+func (l logger) Error(msg string, fields ...request.Field) {
+	l.logger.WithFields(logrusFields(fields)).Error(msg)
+}
+
+func logrusFields(fields []request.Field) logrus.Fields {
+	converted := make(logrus.Fields, len(fields))
+	for _, field := range fields {
+		converted[field.Key] = field.Value
+	}
+	return converted
+}
@@ -0,0 +1,47 @@
+package request
+
+import "net/http"
+
+// Get returns a GET request for uri. An invalid uri is reported by Do.
+func Get(uri string) *httpRequest {
+	return newRequest(http.MethodGet, uri)
+}
+
+// Head returns a HEAD request for uri. An invalid uri is reported by Do.
+func Head(uri string) *httpRequest {
+	return newRequest(http.MethodHead, uri)
+}
+
+// Post returns a POST request for uri. An invalid uri is reported by Do.
+func Post(uri string) *httpRequest {
+	return newRequest(http.MethodPost, uri)
+}
+
+// Put returns a PUT request for uri. An invalid uri is reported by Do.
+func Put(uri string) *httpRequest {
+	return newRequest(http.MethodPut, uri)
+}
+
+// Patch returns a PATCH request for uri. An invalid uri is reported by Do.
+func Patch(uri string) *httpRequest {
+	return newRequest(http.MethodPatch, uri)
+}
+
+// Delete returns a DELETE request for uri. An invalid uri is reported by Do.
+func Delete(uri string) *httpRequest {
+	return newRequest(http.MethodDelete, uri)
+}
+
+// Options returns an OPTIONS request for uri. An invalid uri is reported by Do.
+func Options(uri string) *httpRequest {
+	return newRequest(http.MethodOptions, uri)
+}
+
+func newRequest(method, uri string) *httpRequest {
+	// New only fails if http.NewRequest rejects an empty method and URL,
+	// which it never does.
+	h, _ := New(nil)
+	h.request.Method = method
+	h.SetURI(uri)
+	return h
+}
@@ -0,0 +1,119 @@
+package request
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"time"
+)
+
+// Error classes reported in MetricsEvent.ErrorClass.
+const (
+	ErrorClassNone        = ""
+	ErrorClassTimeout     = "timeout"
+	ErrorClassCanceled    = "canceled"
+	ErrorClassCircuitOpen = "circuit_open"
+	ErrorClassOther       = "error"
+)
+
+// MetricsEvent describes a finished call to Do.
+type MetricsEvent struct {
+	Method string
+	Host   string
+	// StatusCode is the status of the last response received, or 0 if no
+	// response was received.
+	StatusCode int
+	Attempts   int
+	Duration   time.Duration
+	ErrorClass string
+	// Tags are the tags set with SetTag.
+	Tags map[string]string
+}
+
+// Metrics receives an event for every call to Do. A Prometheus
+// implementation lives in the promrequest package.
+type Metrics interface {
+	ObserveRequest(event MetricsEvent)
+}
+
+var (
+	defaultMetricsMutex sync.RWMutex
+	defaultMetrics      Metrics
+)
+
+// SetDefaultMetrics sets the Metrics used by requests that do not set their
+// own.
+func SetDefaultMetrics(metrics Metrics) {
+	defaultMetricsMutex.Lock()
+	defer defaultMetricsMutex.Unlock()
+	defaultMetrics = metrics
+}
+
+// SetMetrics reports this request's outcome to metrics.
+func (h *httpRequest) SetMetrics(metrics Metrics) *httpRequest {
+	h.metrics = metrics
+	return h
+}
+
+// SetTag attaches a tag, such as an operation name or tenant ID, to the
+// request. Tags are reported to Metrics and added to every log entry of the
+// request.
+func (h *httpRequest) SetTag(key, value string) *httpRequest {
+	if h.tags == nil {
+		h.tags = make(map[string]string)
+	}
+	h.tags[key] = value
+	h.logger = newTaggedLogger(h.logger, h.tags)
+	return h
+}
+
+func (h *httpRequest) observe(start time.Time, err error) {
+	metrics := h.metrics
+	if metrics == nil {
+		defaultMetricsMutex.RLock()
+		metrics = defaultMetrics
+		defaultMetricsMutex.RUnlock()
+	}
+	if metrics == nil {
+		return
+	}
+
+	var tags map[string]string
+	if h.tags != nil {
+		tags = make(map[string]string, len(h.tags))
+		for key, value := range h.tags {
+			tags[key] = value
+		}
+	}
+
+	metrics.ObserveRequest(MetricsEvent{
+		Method:     h.request.Method,
+		Host:       h.request.URL.Host,
+		StatusCode: h.lastStatus,
+		Attempts:   h.attempts,
+		Duration:   time.Since(start),
+		ErrorClass: classifyError(err),
+		Tags:       tags,
+	})
+}
+
+func classifyError(err error) string {
+	if err == nil {
+		return ErrorClassNone
+	}
+	if errors.Is(err, ErrCircuitOpen) {
+		return ErrorClassCircuitOpen
+	}
+	if errors.Is(err, context.Canceled) {
+		return ErrorClassCanceled
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ErrorClassTimeout
+	}
+	var netError net.Error
+	if errors.As(err, &netError) && netError.Timeout() {
+		return ErrorClassTimeout
+	}
+	return ErrorClassOther
+}
@@ -0,0 +1,45 @@
+package request
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type recordingMetrics struct {
+	events []MetricsEvent
+}
+
+func (m *recordingMetrics) ObserveRequest(event MetricsEvent) {
+	m.events = append(m.events, event)
+}
+
+func TestTagsReachMetricsAndLogs(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	metrics := &recordingMetrics{}
+	logger := &recordingLogger{}
+	response, err := Get(server.URL).SetLogger(logger).SetTag("operation", "list-users").
+		SetMetrics(metrics).SetTag("tenant", "acme").Do()
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+
+	if len(metrics.events) != 1 {
+		t.Fatalf("observed %d events, want 1", len(metrics.events))
+	}
+	tags := metrics.events[0].Tags
+	if tags["operation"] != "list-users" || tags["tenant"] != "acme" {
+		t.Errorf("event tags = %v", tags)
+	}
+
+	entries := logger.find("Attempt finished")
+	if len(entries) != 1 {
+		t.Fatalf("logged %d finished attempts, want 1", len(entries))
+	}
+	if entries[0].fields["operation"] != "list-users" || entries[0].fields["tenant"] != "acme" {
+		t.Errorf("log fields = %v", entries[0].fields)
+	}
+}
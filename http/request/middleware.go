@@ -0,0 +1,70 @@
+package request
+
+import (
+	"context"
+	"net/http"
+	"sync"
+)
+
+// RoundTripFunc sends a single attempt of a request.
+type RoundTripFunc func(*http.Request) (*http.Response, error)
+
+// closeBody closes the body of a request abandoned before it reaches the
+// transport, which would otherwise close it, so a body backed by a file is not
+// leaked.
+func closeBody(request *http.Request) {
+	if request.Body != nil {
+		request.Body.Close()
+	}
+}
+
+// Middleware wraps a RoundTripFunc, e.g. to inject headers, log or record
+// metrics. It runs once per attempt, including retries.
+type Middleware func(next RoundTripFunc) RoundTripFunc
+
+var (
+	defaultMiddlewareMutex sync.RWMutex
+	defaultMiddleware      []Middleware
+)
+
+// Use appends middleware to the default chain run by every request, ahead of
+// any middleware registered on the request itself.
+func Use(middleware ...Middleware) {
+	defaultMiddlewareMutex.Lock()
+	defer defaultMiddlewareMutex.Unlock()
+	defaultMiddleware = append(defaultMiddleware, middleware...)
+}
+
+// Use appends middleware to this request's chain. Middleware registered first
+// runs outermost.
+func (h *httpRequest) Use(middleware ...Middleware) *httpRequest {
+	h.middleware = append(h.middleware, middleware...)
+	return h
+}
+
+func (h *httpRequest) chain(roundTrip RoundTripFunc) RoundTripFunc {
+	defaultMiddlewareMutex.RLock()
+	middleware := append(append([]Middleware(nil), defaultMiddleware...), h.middleware...)
+	defaultMiddlewareMutex.RUnlock()
+
+	for i := len(middleware) - 1; i >= 0; i-- {
+		roundTrip = middleware[i](roundTrip)
+	}
+	return roundTrip
+}
+
+// roundTripper wraps client.Do in the request's middleware and in the
+// features that act on every attempt, such as rate limiting and signing.
+func (h *httpRequest) roundTripper(client *http.Client) RoundTripFunc {
+	return h.chain(h.authorize(h.limitRate(h.guardCircuit(h.hedge(h.sign(h.dump(client.Do)))))))
+}
+
+type attemptContextKey struct{}
+
+// AttemptFromContext returns the attempt number, starting at 1, of the request
+// whose context is ctx, or 0 outside of a request made by this package. It
+// lets middleware tell retries apart.
+func AttemptFromContext(ctx context.Context) int {
+	attempt, _ := ctx.Value(attemptContextKey{}).(int)
+	return attempt
+}
@@ -0,0 +1,86 @@
+package request
+
+import (
+	"io"
+	"mime/multipart"
+	"sync"
+)
+
+type formPart struct {
+	field    string
+	fileName string
+	value    string
+	reader   io.Reader
+}
+
+// AddFormField adds a plain field to a multipart/form-data body.
+func (h *httpRequest) AddFormField(key, value string) *httpRequest {
+	return h.addFormPart(formPart{field: key, value: value})
+}
+
+// AddFormFile adds a file to a multipart/form-data body. The contents of r are
+// streamed when the request is sent rather than buffered, so retries are
+// disabled for multipart requests.
+func (h *httpRequest) AddFormFile(fieldName, fileName string, r io.Reader) *httpRequest {
+	return h.addFormPart(formPart{field: fieldName, fileName: fileName, reader: r})
+}
+
+func (h *httpRequest) addFormPart(part formPart) *httpRequest {
+	if len(h.formParts) == 0 {
+		h.resetBody()
+	}
+	h.formParts = append(h.formParts, part)
+	return h
+}
+
+// multipartBody encodes form parts into a pipe from a goroutine that is only
+// started once the transport begins reading the body.
+type multipartBody struct {
+	parts  []formPart
+	writer *multipart.Writer
+	reader *io.PipeReader
+	pipe   *io.PipeWriter
+	start  sync.Once
+}
+
+func newMultipartBody(parts []formPart) *multipartBody {
+	reader, pipe := io.Pipe()
+	return &multipartBody{
+		parts:  parts,
+		writer: multipart.NewWriter(pipe),
+		reader: reader,
+		pipe:   pipe,
+	}
+}
+
+func (b *multipartBody) Read(p []byte) (int, error) {
+	b.start.Do(func() { go b.write() })
+	return b.reader.Read(p)
+}
+
+func (b *multipartBody) Close() error {
+	return b.reader.Close()
+}
+
+func (b *multipartBody) write() {
+	for _, part := range b.parts {
+		if part.reader == nil {
+			if err := b.writer.WriteField(part.field, part.value); err != nil {
+				b.pipe.CloseWithError(err)
+				return
+			}
+			continue
+		}
+
+		partWriter, err := b.writer.CreateFormFile(part.field, part.fileName)
+		if err != nil {
+			b.pipe.CloseWithError(err)
+			return
+		}
+		if _, err = io.Copy(partWriter, part.reader); err != nil {
+			b.pipe.CloseWithError(err)
+			return
+		}
+	}
+	b.pipe.CloseWithError(b.writer.Close())
+}
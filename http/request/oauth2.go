@@ -0,0 +1,175 @@
+package request
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// oauth2ExpiryMargin renews tokens slightly before they expire so they do not
+// lapse in flight.
+const oauth2ExpiryMargin = 10 * time.Second
+
+// oauth2Source fetches and caches the tokens of one client registration.
+type oauth2Source struct {
+	mu           sync.Mutex
+	tokenURL     string
+	clientID     string
+	clientSecret string
+	scopes       []string
+
+	accessToken  string
+	refreshToken string
+	expiry       time.Time
+}
+
+type oauth2TokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+var (
+	oauth2SourcesMutex sync.Mutex
+	oauth2Sources      = make(map[string]*oauth2Source)
+)
+
+// oauth2SourceFor returns the shared source for a client registration, so
+// requests using the same credentials share their token.
+func oauth2SourceFor(tokenURL, clientID, clientSecret string, scopes []string) *oauth2Source {
+	key := strings.Join([]string{tokenURL, clientID, clientSecret, strings.Join(scopes, " ")}, "\x00")
+	oauth2SourcesMutex.Lock()
+	defer oauth2SourcesMutex.Unlock()
+	source, ok := oauth2Sources[key]
+	if !ok {
+		source = &oauth2Source{
+			tokenURL:     tokenURL,
+			clientID:     clientID,
+			clientSecret: clientSecret,
+			scopes:       append([]string(nil), scopes...),
+		}
+		oauth2Sources[key] = source
+	}
+	return source
+}
+
+// SetOAuth2 authorizes the request with a bearer token obtained from tokenURL
+// with the OAuth2 client credentials grant. Tokens are cached and shared by
+// every request using the same credentials, renewed with the refresh token
+// when the server issued one, and fetched again on expiry. If the server
+// rejects a token with 401, a new one is fetched and the request is resent
+// once.
+func (h *httpRequest) SetOAuth2(tokenURL, clientID, clientSecret string, scopes ...string) *httpRequest {
+	h.oauth2 = oauth2SourceFor(tokenURL, clientID, clientSecret, scopes)
+	return h
+}
+
+// token returns a valid access token, fetching one if needed. Concurrent
+// callers wait for a single fetch.
+func (s *oauth2Source) token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.accessToken != "" && time.Now().Add(oauth2ExpiryMargin).Before(s.expiry) {
+		return s.accessToken, nil
+	}
+
+	if s.refreshToken != "" {
+		err := s.fetch(ctx, url.Values{
+			"grant_type":    {"refresh_token"},
+			"refresh_token": {s.refreshToken},
+		})
+		if err == nil {
+			return s.accessToken, nil
+		}
+		s.refreshToken = ""
+	}
+
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(s.scopes) > 0 {
+		form.Set("scope", strings.Join(s.scopes, " "))
+	}
+	if err := s.fetch(ctx, form); err != nil {
+		return "", err
+	}
+	return s.accessToken, nil
+}
+
+func (s *oauth2Source) fetch(ctx context.Context, form url.Values) error {
+	var response oauth2TokenResponse
+	err := Post(s.tokenURL).
+		SetContext(ctx).
+		SetBasicAuth(url.QueryEscape(s.clientID), url.QueryEscape(s.clientSecret)).
+		SetFormPayload(form).
+		DoAndDecode(&response)
+	if err != nil {
+		return fmt.Errorf("Could not fetch OAuth2 token: %w", err)
+	}
+	if response.AccessToken == "" {
+		return errors.New("Could not fetch OAuth2 token: response has no access_token")
+	}
+
+	s.accessToken = response.AccessToken
+	if response.RefreshToken != "" {
+		s.refreshToken = response.RefreshToken
+	}
+	if response.ExpiresIn > 0 {
+		s.expiry = time.Now().Add(time.Duration(response.ExpiresIn) * time.Second)
+	} else {
+		// Without expires_in the token is valid until the server rejects it.
+		s.expiry = time.Now().Add(100 * 365 * 24 * time.Hour)
+	}
+	return nil
+}
+
+// invalidate discards token if it is still the cached one.
+func (s *oauth2Source) invalidate(token string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.accessToken == token {
+		s.accessToken = ""
+	}
+}
+
+func (h *httpRequest) authorize(roundTrip RoundTripFunc) RoundTripFunc {
+	if h.oauth2 == nil {
+		return roundTrip
+	}
+	source := h.oauth2
+
+	return func(request *http.Request) (*http.Response, error) {
+		token, err := source.token(request.Context())
+		if err != nil {
+			closeBody(request)
+			return nil, err
+		}
+		authorized := request.Clone(request.Context())
+		authorized.Header.Set("Authorization", "Bearer "+token)
+		response, err := roundTrip(authorized)
+		if err != nil || response.StatusCode != http.StatusUnauthorized {
+			return response, err
+		}
+		if request.Body != nil && request.GetBody == nil {
+			return response, nil
+		}
+
+		discardResponse(response)
+		source.invalidate(token)
+		if token, err = source.token(request.Context()); err != nil {
+			return nil, err
+		}
+		authorized = request.Clone(request.Context())
+		authorized.Header.Set("Authorization", "Bearer "+token)
+		if request.GetBody != nil {
+			if authorized.Body, err = request.GetBody(); err != nil {
+				return nil, err
+			}
+		}
+		return roundTrip(authorized)
+	}
+}
@@ -0,0 +1,32 @@
+package request
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestOAuth2ClosesBodyWhenTokenFails(t *testing.T) {
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer tokenServer.Close()
+
+	sent := false
+	next := func(*http.Request) (*http.Response, error) {
+		sent = true
+		return nil, nil
+	}
+	roundTrip := Post("http://oauth2-body.test").SetOAuth2(tokenServer.URL, "id", "secret").authorize(next)
+
+	request, body := newBodyRequest(t, "http://oauth2-body.test")
+	if _, err := roundTrip(request); err == nil {
+		t.Fatal("request was authorized without a token")
+	}
+	if sent {
+		t.Error("request was sent without a token")
+	}
+	if !body.closed {
+		t.Error("body of the abandoned request was not closed")
+	}
+}
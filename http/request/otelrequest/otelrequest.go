@@ -0,0 +1,65 @@
+// Package otelrequest traces requests made with the request package using
+// OpenTelemetry.
+package otelrequest
+
+import (
+	"net/http"
+
+	"github.com/metamemelord/go-utilities/http/request"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/metamemelord/go-utilities/http/request/otelrequest"
+
+// Middleware returns a request.Middleware that starts a client span for every
+// attempt, propagates it with a W3C traceparent header and records the
+// response status or error on it. A nil provider uses the global provider.
+func Middleware(provider trace.TracerProvider) request.Middleware {
+	if provider == nil {
+		provider = otel.GetTracerProvider()
+	}
+	tracer := provider.Tracer(instrumentationName, trace.WithSchemaURL(semconv.SchemaURL))
+	propagator := propagation.TraceContext{}
+
+	return func(next request.RoundTripFunc) request.RoundTripFunc {
+		return func(r *http.Request) (*http.Response, error) {
+			ctx, span := tracer.Start(r.Context(), "HTTP "+r.Method,
+				trace.WithSpanKind(trace.SpanKindClient),
+				trace.WithAttributes(
+					semconv.HTTPMethodKey.String(r.Method),
+					semconv.HTTPURLKey.String(r.URL.Redacted()),
+				))
+			defer span.End()
+
+			if attempt := request.AttemptFromContext(r.Context()); attempt > 1 {
+				span.SetAttributes(semconv.HTTPResendCountKey.Int(attempt - 1))
+			}
+
+			r = r.Clone(ctx)
+			propagator.Inject(ctx, propagation.HeaderCarrier(r.Header))
+
+			response, err := next(r)
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+				return response, err
+			}
+
+			span.SetAttributes(semconv.HTTPStatusCodeKey.Int(response.StatusCode))
+			if response.StatusCode >= 500 {
+				span.SetStatus(codes.Error, response.Status)
+			}
+			return response, nil
+		}
+	}
+}
+
+// Enable adds Middleware(provider) to the default middleware chain so every
+// request made through the request package is traced.
+func Enable(provider trace.TracerProvider) {
+	request.Use(Middleware(provider))
+}
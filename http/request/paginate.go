@@ -0,0 +1,240 @@
+package request
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxPageRetries is how many times a page answered with 429 is requested
+// again before the iteration fails.
+const maxPageRetries = 5
+
+// Paginator returns the URL of the page following page, or nil if page is the
+// last one.
+type Paginator func(page *Response) (*url.URL, error)
+
+// LinkPagination follows the rel="next" link of the Link header (RFC 8288).
+func LinkPagination() Paginator {
+	return func(page *Response) (*url.URL, error) {
+		for _, link := range parseLinks(page.Raw().Header["Link"]) {
+			for _, rel := range strings.Fields(link.rel) {
+				if strings.EqualFold(rel, "next") {
+					return page.Raw().Request.URL.Parse(link.target)
+				}
+			}
+		}
+		return nil, nil
+	}
+}
+
+// CursorPagination reads the cursor of the next page at cursorPath in the
+// JSON body (see DoJSONPath) and sends it in the query parameter param. The
+// iteration ends when the cursor is missing, null or empty.
+func CursorPagination(cursorPath, param string) Paginator {
+	return func(page *Response) (*url.URL, error) {
+		var document interface{}
+		if err := page.JSON(&document); err != nil {
+			return nil, err
+		}
+		cursor, err := lookupJSONPath(document, cursorPath)
+		if err != nil || cursor == nil {
+			return nil, nil
+		}
+		value := fmt.Sprint(cursor)
+		if f, ok := cursor.(float64); ok {
+			value = strconv.FormatFloat(f, 'f', -1, 64)
+		}
+		if value == "" {
+			return nil, nil
+		}
+		return withQueryParam(page.Raw().Request.URL, param, value), nil
+	}
+}
+
+// PagePagination increments the page number in the query parameter param,
+// starting from 1 when the first request does not set it. The iteration ends
+// at the first page whose JSON array at itemsPath is empty; an empty itemsPath
+// refers to the whole body.
+func PagePagination(param, itemsPath string) Paginator {
+	return func(page *Response) (*url.URL, error) {
+		items, err := pageItems(page, itemsPath)
+		if err != nil || items == 0 {
+			return nil, err
+		}
+		current := page.Raw().Request.URL
+		number, err := strconv.Atoi(current.Query().Get(param))
+		if err != nil {
+			number = 1
+		}
+		return withQueryParam(current, param, strconv.Itoa(number+1)), nil
+	}
+}
+
+// OffsetPagination advances the offset in the query parameter param by the
+// number of items in the JSON array at itemsPath, starting from 0. The
+// iteration ends at the first empty page.
+func OffsetPagination(param, itemsPath string) Paginator {
+	return func(page *Response) (*url.URL, error) {
+		items, err := pageItems(page, itemsPath)
+		if err != nil || items == 0 {
+			return nil, err
+		}
+		current := page.Raw().Request.URL
+		offset, _ := strconv.Atoi(current.Query().Get(param))
+		return withQueryParam(current, param, strconv.Itoa(offset+items)), nil
+	}
+}
+
+func pageItems(page *Response, itemsPath string) (int, error) {
+	var document interface{}
+	if err := page.JSON(&document); err != nil {
+		return 0, err
+	}
+	items := document
+	if itemsPath != "" {
+		var err error
+		if items, err = lookupJSONPath(document, itemsPath); err != nil {
+			return 0, err
+		}
+	}
+	array, ok := items.([]interface{})
+	if !ok {
+		return 0, fmt.Errorf("JSON path %q is not an array", itemsPath)
+	}
+	return len(array), nil
+}
+
+func withQueryParam(u *url.URL, key, value string) *url.URL {
+	next := *u
+	query := next.Query()
+	query.Set(key, value)
+	next.RawQuery = query.Encode()
+	return &next
+}
+
+type link struct {
+	target string
+	rel    string
+}
+
+// parseLinks parses Link header values of the form
+// `<https://example.com/?page=2>; rel="next", <...>; rel="last"`.
+func parseLinks(values []string) []link {
+	var links []link
+	for _, value := range values {
+		for value != "" {
+			start := strings.IndexByte(value, '<')
+			end := strings.IndexByte(value, '>')
+			if start < 0 || end < start {
+				break
+			}
+			current := link{target: value[start+1 : end]}
+			value = value[end+1:]
+
+			params := value
+			if next := strings.IndexByte(value, '<'); next >= 0 {
+				params, value = value[:next], value[next:]
+			} else {
+				value = ""
+			}
+			for _, param := range strings.Split(params, ";") {
+				name, arg, ok := strings.Cut(strings.TrimSpace(param), "=")
+				if ok && strings.EqualFold(strings.TrimSpace(name), "rel") {
+					current.rel = strings.Trim(strings.TrimSpace(arg), `",`)
+				}
+			}
+			links = append(links, current)
+		}
+	}
+	return links
+}
+
+// PageIterator walks the pages of a list endpoint. Use it like a
+// bufio.Scanner:
+//
+//	pages := request.Get(uri).Paginate(request.LinkPagination())
+//	for pages.Next() {
+//		page := pages.Page()
+//	}
+//	if err := pages.Err(); err != nil {
+//	}
+type PageIterator struct {
+	base      *httpRequest
+	paginator Paginator
+	next      *url.URL
+	page      *Response
+	err       error
+}
+
+// Paginate returns an iterator that sends the request for the first page and
+// uses paginator to find the following ones. Pages answered with 429 Too Many
+// Requests are requested again after the Retry-After delay, or an increasing
+// delay from one second, up to five times. The builder must not be modified
+// while iterating.
+func (h *httpRequest) Paginate(paginator Paginator) *PageIterator {
+	return &PageIterator{base: h, paginator: paginator}
+}
+
+// Next fetches the next page and reports whether there is one.
+func (it *PageIterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+	if it.page != nil {
+		next, err := it.paginator(it.page)
+		if err != nil || next == nil {
+			it.page, it.err = nil, err
+			return false
+		}
+		it.next = next
+	}
+	it.page, it.err = it.fetch()
+	return it.err == nil
+}
+
+// Page returns the page fetched by the last call to Next.
+func (it *PageIterator) Page() *Response {
+	return it.page
+}
+
+// Err returns the error that ended the iteration, if any.
+func (it *PageIterator) Err() error {
+	return it.err
+}
+
+func (it *PageIterator) fetch() (*Response, error) {
+	for attempt := 1; ; attempt++ {
+		request := it.base.copyBuilder()
+		if it.next != nil {
+			request.request.URL = it.next
+		}
+		page, err := request.DoWrapped()
+		if err != nil {
+			return nil, err
+		}
+
+		if page.StatusCode() == http.StatusTooManyRequests && attempt <= maxPageRetries {
+			delay := it.base.retryAfter(page.Raw())
+			if delay <= 0 {
+				delay = time.Second << (attempt - 1)
+			}
+			it.base.logger.Info("Rate limited while paginating", F("url", redactedURL(page.Raw().Request.URL)), F("delay", delay))
+			timer := time.NewTimer(delay)
+			select {
+			case <-it.base.request.Context().Done():
+				timer.Stop()
+				return nil, it.base.request.Context().Err()
+			case <-timer.C:
+			}
+			continue
+		}
+		if !page.IsSuccess() {
+			return nil, page.statusError()
+		}
+		return page, nil
+	}
+}
@@ -0,0 +1,32 @@
+package request
+
+import (
+	"net/url"
+	"strings"
+)
+
+// SetPathParam substitutes value, path-escaped, for the placeholder {key} in
+// the URI path, e.g. "/users/{id}". Parameters apply to the current URI and
+// to any URI set later.
+func (h *httpRequest) SetPathParam(key, value string) *httpRequest {
+	if h.pathParams == nil {
+		h.pathParams = make(map[string]string)
+	}
+	h.pathParams[key] = value
+	if h.request.URL != nil {
+		expandPath(h.request.URL, map[string]string{key: value})
+	}
+	return h
+}
+
+// expandPath replaces the {key} placeholders in u's path with their values.
+func expandPath(u *url.URL, params map[string]string) {
+	path, rawPath := u.Path, u.EscapedPath()
+	for key, value := range params {
+		placeholder := "{" + key + "}"
+		path = strings.Replace(path, placeholder, value, -1)
+		rawPath = strings.Replace(rawPath, placeholder, url.PathEscape(value), -1)
+		rawPath = strings.Replace(rawPath, url.PathEscape(placeholder), url.PathEscape(value), -1)
+	}
+	u.Path, u.RawPath = path, rawPath
+}
@@ -0,0 +1,54 @@
+// Package promrequest exports metrics for requests made with the request
+// package to Prometheus.
+package promrequest
+
+import (
+	"strconv"
+
+	"github.com/metamemelord/go-utilities/http/request"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Collector implements request.Metrics and prometheus.Collector.
+type Collector struct {
+	duration *prometheus.HistogramVec
+	attempts *prometheus.HistogramVec
+}
+
+var labels = []string{"method", "host", "status", "error"}
+
+// New returns a Collector whose metric names are prefixed with namespace.
+// Register it with a prometheus.Registerer and pass it to request.SetMetrics
+// or request.SetDefaultMetrics.
+func New(namespace string) *Collector {
+	return &Collector{
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_client_request_duration_seconds",
+			Help:      "Duration of outgoing HTTP requests, including retries.",
+			Buckets:   prometheus.DefBuckets,
+		}, labels),
+		attempts: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_client_request_attempts",
+			Help:      "Number of attempts made per outgoing HTTP request.",
+			Buckets:   []float64{1, 2, 3, 4, 5, 10},
+		}, labels),
+	}
+}
+
+func (c *Collector) ObserveRequest(event request.MetricsEvent) {
+	values := []string{event.Method, event.Host, strconv.Itoa(event.StatusCode), event.ErrorClass}
+	c.duration.WithLabelValues(values...).Observe(event.Duration.Seconds())
+	c.attempts.WithLabelValues(values...).Observe(float64(event.Attempts))
+}
+
+func (c *Collector) Describe(descs chan<- *prometheus.Desc) {
+	c.duration.Describe(descs)
+	c.attempts.Describe(descs)
+}
+
+func (c *Collector) Collect(metrics chan<- prometheus.Metric) {
+	c.duration.Collect(metrics)
+	c.attempts.Collect(metrics)
+}
@@ -0,0 +1,64 @@
+package request
+
+import (
+	"net/url"
+)
+
+// AddQueryParam appends a query parameter, keeping any values the URI already
+// has for key.
+func (h *httpRequest) AddQueryParam(key, value string) *httpRequest {
+	if h.query == nil {
+		h.query = make(url.Values)
+	}
+	h.query.Add(key, value)
+
+	query := h.request.URL.Query()
+	query.Add(key, value)
+	h.request.URL.RawQuery = query.Encode()
+	return h
+}
+
+// SetQueryParams sets the query parameters in values, replacing any existing
+// values for the same keys and leaving other parameters untouched.
+func (h *httpRequest) SetQueryParams(values url.Values) *httpRequest {
+	if h.query == nil {
+		h.query = make(url.Values)
+	}
+	if h.replacedQuery == nil {
+		h.replacedQuery = make(map[string]bool)
+	}
+	query := h.request.URL.Query()
+	for key, keyValues := range values {
+		h.query[key] = append([]string(nil), keyValues...)
+		h.replacedQuery[key] = true
+		query[key] = append([]string(nil), keyValues...)
+	}
+	h.request.URL.RawQuery = query.Encode()
+	return h
+}
+
+// mergeQuery adds the parameters set through the builder to u, so they
+// survive a later SetURI call. Keys set with SetQueryParams replace the values
+// u has for them, whichever of the two calls came first, while keys only
+// added with AddQueryParam are appended to them.
+func (h *httpRequest) mergeQuery(u *url.URL) {
+	if len(h.query) == 0 {
+		return
+	}
+	query := u.Query()
+	for key, values := range h.query {
+		if h.replacedQuery[key] {
+			query[key] = append([]string(nil), values...)
+		} else {
+			query[key] = append(query[key], values...)
+		}
+	}
+	u.RawQuery = query.Encode()
+}
+
+// SetFormPayload encodes values as an application/x-www-form-urlencoded
+// payload and sets the matching Content-Type.
+func (h *httpRequest) SetFormPayload(values url.Values) *httpRequest {
+	return h.SetHeader("Content-Type", "application/x-www-form-urlencoded").
+		SetPayload([]byte(values.Encode()))
+}
@@ -0,0 +1,63 @@
+package request
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestQueryParamsAndSetURI(t *testing.T) {
+	const uri = "http://api.test/items?page=1&tag=a"
+	tests := []struct {
+		name  string
+		build func() *httpRequest
+		want  string
+	}{
+		{
+			name:  "set after URI",
+			build: func() *httpRequest { return Get(uri).SetQueryParams(url.Values{"page": {"2"}}) },
+			want:  "page=2&tag=a",
+		},
+		{
+			name: "set before URI",
+			build: func() *httpRequest {
+				return Get("http://api.test").SetQueryParams(url.Values{"page": {"2"}}).SetURI(uri)
+			},
+			want: "page=2&tag=a",
+		},
+		{
+			name:  "add after URI",
+			build: func() *httpRequest { return Get(uri).AddQueryParam("tag", "b") },
+			want:  "page=1&tag=a&tag=b",
+		},
+		{
+			name:  "add before URI",
+			build: func() *httpRequest { return Get("http://api.test").AddQueryParam("tag", "b").SetURI(uri) },
+			want:  "page=1&tag=a&tag=b",
+		},
+		{
+			name: "add to a set key before URI",
+			build: func() *httpRequest {
+				return Get("http://api.test").SetQueryParams(url.Values{"tag": {"b"}}).AddQueryParam("tag", "c").SetURI(uri)
+			},
+			want: "page=1&tag=b&tag=c",
+		},
+		{
+			name: "cloned before URI",
+			build: func() *httpRequest {
+				return Get("http://api.test").SetQueryParams(url.Values{"page": {"2"}}).Clone().SetURI(uri)
+			},
+			want: "page=2&tag=a",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			h := test.build()
+			if h.err != nil {
+				t.Fatal(h.err)
+			}
+			if got := h.request.URL.RawQuery; got != test.want {
+				t.Errorf("query = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package request
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/metamemelord/go-utilities/ratelimit"
+)
+
+var (
+	limitersMutex sync.Mutex
+	limiters      = make(map[string]*ratelimit.TokenBucket)
+)
+
+// limiterFor returns the limiter shared by all requests to host, creating it
+// with the given rate and burst the first time.
+func limiterFor(host string, rate float64, burst int) *ratelimit.TokenBucket {
+	limitersMutex.Lock()
+	defer limitersMutex.Unlock()
+	limiter, ok := limiters[host]
+	if !ok {
+		limiter = ratelimit.NewTokenBucket(rate, burst)
+		limiters[host] = limiter
+	}
+	return limiter
+}
+
+// SetRateLimit throttles requests with a token bucket shared by every request
+// to the same host, allowing requestsPerSecond on average with bursts of up
+// to burst requests. Every attempt, including retries, takes a token. The
+// first limit configured for a host applies to all requests to it.
+func (h *httpRequest) SetRateLimit(requestsPerSecond float64, burst int) *httpRequest {
+	h.rateLimit = requestsPerSecond
+	h.rateBurst = burst
+	return h
+}
+
+func (h *httpRequest) limitRate(roundTrip RoundTripFunc) RoundTripFunc {
+	if h.rateLimit <= 0 {
+		return roundTrip
+	}
+	rate, burst := h.rateLimit, h.rateBurst
+	return func(request *http.Request) (*http.Response, error) {
+		if err := limiterFor(request.URL.Host, rate, burst).Wait(request.Context()); err != nil {
+			closeBody(request)
+			return nil, err
+		}
+		return roundTrip(request)
+	}
+}
@@ -0,0 +1,34 @@
+package request
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestRateLimitClosesAbandonedBody(t *testing.T) {
+	limitersMutex.Lock()
+	delete(limiters, "ratelimit-body.test")
+	limitersMutex.Unlock()
+
+	ok := func(request *http.Request) (*http.Response, error) {
+		request.Body.Close()
+		return &http.Response{StatusCode: http.StatusOK}, nil
+	}
+	roundTrip := Get("http://ratelimit-body.test").SetRateLimit(0.001, 1).limitRate(ok)
+
+	request, _ := newBodyRequest(t, "http://ratelimit-body.test")
+	if _, err := roundTrip(request); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	request, body := newBodyRequest(t, "http://ratelimit-body.test")
+	if _, err := roundTrip(request.WithContext(ctx)); err == nil {
+		t.Fatal("rate limited request with a cancelled context was sent")
+	}
+	if !body.closed {
+		t.Error("body of the abandoned request was not closed")
+	}
+}
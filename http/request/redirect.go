@@ -0,0 +1,55 @@
+package request
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// defaultMaxRedirects matches the limit of http.Client's default policy.
+const defaultMaxRedirects = 10
+
+// SetFollowRedirects controls whether redirects are followed. When they are
+// not, Do returns the redirect response itself.
+func (h *httpRequest) SetFollowRedirects(follow bool) *httpRequest {
+	h.noRedirects = !follow
+	return h
+}
+
+// SetMaxRedirects sets how many redirects are followed before Do fails.
+func (h *httpRequest) SetMaxRedirects(n int) *httpRequest {
+	h.maxRedirects = n
+	return h
+}
+
+// OnRedirect registers a callback run before each redirect is followed. req
+// is the upcoming request and via the requests made so far, oldest first. The
+// callback may modify req, e.g. to drop credentials when the host changes, or
+// return an error to stop following redirects.
+func (h *httpRequest) OnRedirect(fn func(req *http.Request, via []*http.Request) error) *httpRequest {
+	h.onRedirect = fn
+	return h
+}
+
+// checkRedirect returns the http.Client redirect policy for the request, or
+// nil to use the default policy.
+func (h *httpRequest) checkRedirect() func(*http.Request, []*http.Request) error {
+	if !h.noRedirects && h.maxRedirects == 0 && h.onRedirect == nil {
+		return nil
+	}
+	return func(req *http.Request, via []*http.Request) error {
+		if h.noRedirects {
+			return http.ErrUseLastResponse
+		}
+		maxRedirects := h.maxRedirects
+		if maxRedirects == 0 {
+			maxRedirects = defaultMaxRedirects
+		}
+		if len(via) > maxRedirects {
+			return fmt.Errorf("Stopped after %d redirects", maxRedirects)
+		}
+		if h.onRedirect != nil {
+			return h.onRedirect(req, via)
+		}
+		return nil
+	}
+}
@@ -3,24 +3,105 @@ package request
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"log"
+	"net"
 	"net/http"
+	"net/http/httptrace"
+	"net/textproto"
 	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/metamemelord/go-utilities/retry"
 	"go.uber.org/multierr"
 )
 
 type httpRequest struct {
-	request *http.Request
-	timeout time.Duration
-	payload []byte
-	header  map[string]string
-	retries uint8
-	logger  *log.Logger
+	// Accessed atomically; kept first for 64-bit alignment on 32-bit platforms.
+	bytesSent     int64
+	bytesReceived int64
+
+	request       *http.Request
+	timeout       time.Duration
+	totalDeadline time.Duration
+	payload       []byte
+	header        map[string]string
+	retries       uint8
+	logger        Logger
+	err           error
+
+	attempts     int
+	lastStatus   int
+	metrics      Metrics
+	tags         map[string]string
+	singleflight bool
+	on1xx        func(code int, header http.Header)
+	compression  string
+	requireUTF8  bool
+
+	maxPayloadSize int64
+
+	responseCookies []*http.Cookie
+	jar             http.CookieJar
+	cache           CacheStore
+
+	httpClient      *http.Client
+	customTransport http.RoundTripper
+	tracker         *requestTracker
+	envHeaders      []envHeader
+	query           url.Values
+	replacedQuery   map[string]bool
+	pathParams      map[string]string
+
+	sensitiveHeaders map[string]bool
+	oauth2           *oauth2Source
+	signer           Signer
+	debug            bool
+	collectTimings   bool
+	onTimings        func(Timings)
+	timings          []Timings
+	debugBodyLimit   int
+	middleware       []Middleware
+
+	expectedETag string
+	etagMaxWait  time.Duration
+
+	transportOptions  transportOptions
+	clientCertificate *tls.Certificate
+	ssrf              *ssrfGuard
+
+	bodyChannel <-chan []byte
+	formParts   []formPart
+	rereadable  bool
+
+	bodySource func() (io.ReadCloser, error)
+	bodySize   int64
+	// wireBody is the buffered body as sent, after compression.
+	wireBody []byte
+
+	streamResponse bool
+	circuitBreaker *CircuitBreakerOptions
+	rateLimit      float64
+	rateBurst      int
+	hedgeDelay     time.Duration
+
+	noRedirects  bool
+	maxRedirects int
+	onRedirect   func(req *http.Request, via []*http.Request) error
+
+	retryBudget      *RetryBudget
+	backoff          Backoff
+	retryPolicy      RetryPolicy
+	ignoreRetryAfter bool
+	maxRetryAfter    time.Duration
 }
 
 type byteReaderCloser struct {
@@ -29,6 +110,55 @@ type byteReaderCloser struct {
 
 func (byteReaderCloser) Close() error { return nil }
 
+// errBodyClosed is returned when reading a buffered response body after Close,
+// as it would be for a body read from the network.
+var errBodyClosed = errors.New("Read on closed response body")
+
+// bufferedBody is a response body already read into memory, so its
+// connection has been released. Close only marks it closed.
+type bufferedBody struct {
+	reader *bytes.Reader
+	closed int32
+}
+
+func newBufferedBody(body []byte) *bufferedBody {
+	return &bufferedBody{reader: bytes.NewReader(body)}
+}
+
+func (b *bufferedBody) Read(p []byte) (int, error) {
+	if atomic.LoadInt32(&b.closed) == 1 {
+		return 0, errBodyClosed
+	}
+	return b.reader.Read(p)
+}
+
+func (b *bufferedBody) Close() error {
+	atomic.StoreInt32(&b.closed, 1)
+	return nil
+}
+
+// rereadableBody is a buffered response body that can be read again after
+// seeking to the start; Close rewinds it instead of releasing anything.
+type rereadableBody struct {
+	*bytes.Reader
+}
+
+func (b rereadableBody) Close() error {
+	_, err := b.Seek(0, io.SeekStart)
+	return err
+}
+
+type countingReadCloser struct {
+	io.ReadCloser
+	count *int64
+}
+
+func (c countingReadCloser) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	atomic.AddInt64(c.count, int64(n))
+	return n, err
+}
+
 func New(logger *log.Logger) (*httpRequest, error) {
 	request, err := http.NewRequest("", "", nil)
 
@@ -39,9 +169,9 @@ func New(logger *log.Logger) (*httpRequest, error) {
 	return &httpRequest{
 		request: request,
 		header:  make(map[string]string),
-		retries: 0,
-		timeout: 30 * time.Second,
-		logger:  logger,
+		retries: defaults.Retries,
+		timeout: defaults.Timeout,
+		logger:  StdLogger{Logger: logger},
 	}, nil
 }
 
@@ -50,38 +180,94 @@ func (h *httpRequest) SetContext(ctx context.Context) *httpRequest {
 	return h
 }
 
+var supportedMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodPost:    true,
+	http.MethodPut:     true,
+	http.MethodPatch:   true,
+	http.MethodDelete:  true,
+	http.MethodOptions: true,
+	http.MethodTrace:   true,
+}
+
+// SetMethod sets the HTTP method. An unsupported method is reported by Do.
 func (h *httpRequest) SetMethod(method string) *httpRequest {
-	if method != "GET" &&
-		method != "POST" &&
-		method != "PUT" &&
-		method != "DELETE" {
-		if h.logger != nil {
-			h.logger.Printf("[ERROR] Invalid/Unsupported http method: %s", method)
-		}
-		return nil
+	method = strings.ToUpper(method)
+	if !supportedMethods[method] {
+		h.err = multierr.Append(h.err, fmt.Errorf("Invalid/Unsupported http method %q", method))
+		return h
 	}
 	h.request.Method = method
 	return h
 }
 
+// SetURI sets the request URL. An invalid uri is reported by Do.
 func (h *httpRequest) SetURI(uri string) *httpRequest {
+	if err := h.setURI(uri); err != nil {
+		h.err = multierr.Append(h.err, fmt.Errorf("Invalid URL %s: %v", uri, err))
+		return h
+	}
+	return h
+}
+
+func (h *httpRequest) setURI(uri string) error {
 	u, err := url.Parse(uri)
 	if err != nil {
-		h.logger.Printf("[ERROR] Invalid URL %s", uri)
-		return nil
+		return err
 	}
+	h.mergeQuery(u)
+	expandPath(u, h.pathParams)
 	h.request.URL = u
-	return h
+	return nil
 }
 
 func (h *httpRequest) SetPayloadFromReader(reader io.ReadCloser) *httpRequest {
+	h.resetBody()
 	h.request.Body = reader
 	return h
 }
 
 func (h *httpRequest) SetPayload(payload []byte) *httpRequest {
-	h.request.Body = byteReaderCloser{bytes.NewBuffer(payload)}
+	h.bufferPayload(payload)
+	return h
+}
+
+// bufferPayload installs payload as the request body and sets GetBody so the
+// body can be replayed on 307/308 redirects.
+func (h *httpRequest) bufferPayload(payload []byte) {
+	h.bodyChannel = nil
+	h.formParts = nil
+	h.bodySource = nil
 	h.payload = payload
+	h.installBody(payload)
+}
+
+func (h *httpRequest) installBody(payload []byte) {
+	h.wireBody = payload
+	h.request.ContentLength = int64(len(payload))
+	h.request.Body = countingReadCloser{byteReaderCloser{bytes.NewReader(payload)}, &h.bytesSent}
+	h.request.GetBody = func() (io.ReadCloser, error) {
+		return countingReadCloser{byteReaderCloser{bytes.NewReader(payload)}, &h.bytesSent}, nil
+	}
+}
+
+// BytesSent returns the number of request body bytes sent by the last Do,
+// including bodies resent on retries and redirects.
+func (h *httpRequest) BytesSent() int64 {
+	return atomic.LoadInt64(&h.bytesSent)
+}
+
+// BytesReceived returns the number of response body bytes read so far from
+// the response returned by the last Do.
+func (h *httpRequest) BytesReceived() int64 {
+	return atomic.LoadInt64(&h.bytesReceived)
+}
+
+// SetMaxPayloadSize makes Do fail if the request payload exceeds n bytes.
+// Payloads set from a reader are read at most n+1 bytes before failing.
+func (h *httpRequest) SetMaxPayloadSize(n int64) *httpRequest {
+	h.maxPayloadSize = n
 	return h
 }
 
@@ -97,66 +283,454 @@ func (h *httpRequest) SetCookie(requestCookie *http.Cookie) *httpRequest {
 	return h
 }
 
+// SetTimeout bounds each attempt, including reading the response body. It is
+// the same as SetAttemptTimeout and defaults to 30 seconds.
 func (h *httpRequest) SetTimeout(timeout time.Duration) *httpRequest {
-	h.timeout = timeout * time.Second
+	return h.SetAttemptTimeout(timeout)
+}
+
+// SetAttemptTimeout bounds each attempt, including reading the response body.
+// Zero means no limit.
+func (h *httpRequest) SetAttemptTimeout(timeout time.Duration) *httpRequest {
+	h.timeout = timeout
 	return h
 }
 
+// SetTotalDeadline bounds the whole call, including every retry, the waits
+// between them and reading the response body. Zero means no limit.
+func (h *httpRequest) SetTotalDeadline(d time.Duration) *httpRequest {
+	h.totalDeadline = d
+	return h
+}
+
+// SetRetries sets how many additional attempts are made after the first one
+// fails, so a request is sent at most retries+1 times.
 func (h *httpRequest) SetRetries(retries uint8) *httpRequest {
-	h.retries = retries + 1
+	h.retries = retries
+	return h
+}
+
+// EnableSingleflight makes concurrent identical GET requests (same URL and
+// headers) share a single in-flight call. Every caller receives its own copy
+// of the buffered response. Requests carrying credentials, such as an
+// Authorization, Cookie or API key header, are never shared.
+func (h *httpRequest) EnableSingleflight() *httpRequest {
+	h.singleflight = true
 	return h
 }
 
+// On1xx registers a callback invoked for every informational (1xx) response,
+// such as 100 Continue or 103 Early Hints, received before the final response.
+func (h *httpRequest) On1xx(fn func(code int, header http.Header)) *httpRequest {
+	h.on1xx = fn
+	return h
+}
+
+// MakeBodyReReadable buffers the response body returned by Do so it can be
+// read more than once. The body implements io.Seeker, and closing it rewinds
+// it to the start.
+func (h *httpRequest) MakeBodyReReadable() *httpRequest {
+	h.rereadable = true
+	return h
+}
+
+// Raw returns the underlying *http.Request for fields the builder does not
+// expose. Changes must be made before Do, and replacing the body directly may
+// conflict with payload buffering; prefer the Set* methods.
+func (h *httpRequest) Raw() *http.Request {
+	return h.request
+}
+
 func (h *httpRequest) Do() (*http.Response, error) {
-	if h.request.URL.String() == "" {
-		return nil, fmt.Errorf("Request URI must be specified")
+	start := time.Now()
+	response, err := h.execute()
+	h.observe(start, err)
+	return response, err
+}
+
+func (h *httpRequest) execute() (*http.Response, error) {
+	if h.shutdownTracker().isClosed() {
+		return nil, ErrShutdown
+	}
+	if h.totalDeadline > 0 {
+		parent := h.request.Context()
+		ctx, cancel := context.WithTimeout(parent, h.totalDeadline)
+		h.request = h.request.WithContext(ctx)
+		response, err := h.executeAttempts()
+		h.request = h.request.WithContext(parent)
+		if err != nil {
+			cancel()
+			return response, err
+		}
+		response.Body = cancelOnClose{response.Body, cancel}
+		return response, nil
 	}
+	return h.executeAttempts()
+}
 
-	client := &http.Client{Timeout: h.timeout}
+func (h *httpRequest) executeAttempts() (*http.Response, error) {
+	var response *http.Response
+	var err error
+	if h.expectedETag != "" {
+		response, err = h.doExpectingETag()
+	} else if h.cache != nil && h.request.Method == http.MethodGet && !h.streamResponse {
+		response, err = h.doCached()
+	} else {
+		response, err = h.roundTrip()
+	}
+	if err != nil {
+		return response, err
+	}
+	h.responseCookies = response.Cookies()
 
-	if (h.payload == nil || len(h.payload) == 0) && h.request.Body != nil {
-		requestPayload, err := ioutil.ReadAll(h.request.Body)
-		requestBodyReader := bytes.NewReader(requestPayload)
-		h.request.Body = byteReaderCloser{requestBodyReader}
+	if h.requireUTF8 {
+		if err = checkUTF8Response(response); err != nil {
+			response.Body.Close()
+			return nil, err
+		}
+	}
+
+	if h.rereadable {
+		responsePayload, err := ioutil.ReadAll(response.Body)
+		response.Body.Close()
 		if err != nil {
 			return nil, err
 		}
-		h.payload = requestPayload
+		response.Body = rereadableBody{bytes.NewReader(responsePayload)}
 	}
+	return response, nil
+}
 
-	response, err := client.Do(h.request)
-	if h.retries == 0 {
-		return response, err
+func (h *httpRequest) roundTrip() (*http.Response, error) {
+	if h.singleflight && h.request.Method == "GET" {
+		if key, ok := h.flightKey(); ok {
+			return inFlight.do(key, h.do)
+		}
+	}
+	return h.do()
+}
+
+// flightKey identifies the requests that may share one in-flight call. It
+// reports false for requests carrying credentials, whose responses may be
+// private to the caller.
+func (h *httpRequest) flightKey() (string, bool) {
+	if h.carriesCredentials() {
+		return "", false
+	}
+	keys := make([]string, 0, len(h.request.Header))
+	for key := range h.request.Header {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var key strings.Builder
+	key.WriteString(h.request.Method + " " + h.request.URL.String())
+	for _, name := range keys {
+		key.WriteString("\n" + name + ": " + strings.Join(h.request.Header[name], ", "))
+	}
+	return key.String(), true
+}
+
+// MustDo is like Do but panics if the request fails. It is intended for tests
+// and throwaway scripts only; library and service code should use Do.
+func (h *httpRequest) MustDo() *http.Response {
+	response, err := h.Do()
+	if err != nil {
+		panic(fmt.Sprintf("request: %s %s failed: %v", h.request.Method, redactedURL(h.request.URL), err))
 	}
+	return response
+}
+
+// DryRun runs the same validation and body/header finalization as Do on a
+// copy of the request, without sending it, so request construction can be
+// checked offline. The request itself is left as it was, and bodies set from
+// a reader or channel are not read.
+func (h *httpRequest) DryRun() error {
+	c := h.copyBuilder()
+	defer func() { closeBody(c.request) }()
+	return c.prepare()
+}
+
+func (h *httpRequest) validate() error {
+	if h.err != nil {
+		return h.err
+	}
+	if h.ssrf != nil && (h.httpClient != nil || h.customTransport != nil) {
+		return errors.New("SSRF protection cannot be combined with SetClient or SetTransport")
+	}
+	if h.ssrf != nil && h.transportOptions.proxy != "" {
+		return errors.New("SSRF protection cannot be combined with SetProxy")
+	}
+	if h.request.URL.String() == "" {
+		return ErrMissingURL
+	}
+	return nil
+}
 
-	var retries uint8 = 1
-	log.Println("[INFO]: Starting retries...")
-	for retries <= h.retries {
-		response, err = client.Do(h.request)
+func (h *httpRequest) prepare() error {
+	if err := h.validate(); err != nil {
+		return err
+	}
+
+	if err := h.resolveEnvHeaders(); err != nil {
+		return err
+	}
+
+	if h.bodyChannel != nil {
+		h.request.Body = &channelReader{ctx: h.request.Context(), chunks: h.bodyChannel}
+		return nil
+	}
+
+	if len(h.formParts) > 0 {
+		body := newMultipartBody(h.formParts)
+		h.request.Body = body
+		h.SetHeader("Content-Type", body.writer.FormDataContentType())
+		return nil
+	}
+
+	if h.bodySource != nil {
+		if h.maxPayloadSize > 0 && h.bodySize > h.maxPayloadSize {
+			return fmt.Errorf("%w: exceeds the maximum size of %d bytes", ErrPayloadTooLarge, h.maxPayloadSize)
+		}
+		if err := h.openBodySource(); err != nil {
+			return err
+		}
+		if h.compression == "" {
+			return nil
+		}
+		// Compression needs the whole payload; buffer it below.
+	}
+
+	if len(h.payload) > 0 && h.compression == "" {
+		// Rewind the payload in case an earlier call sent it.
+		h.installBody(h.payload)
+	}
+
+	if (h.payload == nil || len(h.payload) == 0) && h.request.Body != nil {
+		var body io.Reader = h.request.Body
+		if h.maxPayloadSize > 0 {
+			body = io.LimitReader(body, h.maxPayloadSize+1)
+		}
+		requestPayload, err := ioutil.ReadAll(body)
 		if err != nil {
-			if urlError, ok := err.(*url.Error); ok {
-				if urlError.Timeout() {
-					log.Println("[ERROR]: Request timed out")
-				}
-			} else {
-				err = multierr.Append(err, fmt.Errorf("Call failed at retry number %d", retries))
-				log.Println("[ERROR]:", err)
-			}
-			retries++
-			continue
+			return err
 		}
+		h.bufferPayload(requestPayload)
+	}
 
-		responsePayload, err := ioutil.ReadAll(response.Body)
+	if h.maxPayloadSize > 0 && int64(len(h.payload)) > h.maxPayloadSize {
+		return fmt.Errorf("%w: exceeds the maximum size of %d bytes", ErrPayloadTooLarge, h.maxPayloadSize)
+	}
 
+	if h.compression != "" && h.payload != nil {
+		compressedPayload, err := compressPayload(h.compression, h.payload)
 		if err != nil {
+			return err
+		}
+		h.installBody(compressedPayload)
+		h.SetHeader("Content-Encoding", h.compression)
+	}
+	return nil
+}
+
+// fastPath reports whether the request has no body, retries or hooks, in
+// which case payload preparation is skipped and the request is sent as is.
+func (h *httpRequest) fastPath() bool {
+	return h.retries == 0 &&
+		h.request.Body == nil &&
+		h.bodySource == nil &&
+		!h.streamingBody() &&
+		h.on1xx == nil &&
+		len(h.envHeaders) == 0
+}
+
+func (h *httpRequest) do() (*http.Response, error) {
+	h.attempts = 0
+	h.lastStatus = 0
+	h.timings = nil
+	atomic.StoreInt64(&h.bytesSent, 0)
+	atomic.StoreInt64(&h.bytesReceived, 0)
+
+	budget := h.budget()
+	if budget != nil {
+		budget.recordRequest()
+	}
+
+	if h.fastPath() {
+		if err := h.validate(); err != nil {
 			return nil, err
 		}
+		return h.send(h.roundTripper(h.client()))
+	}
 
-		responseBodyReader := bytes.NewReader(responsePayload)
-		response.Body = byteReaderCloser{responseBodyReader}
+	if err := h.prepare(); err != nil {
+		return nil, err
+	}
+	removeKey, err := h.ensureIdempotencyKey()
+	if err != nil {
+		return nil, err
+	}
+	defer removeKey()
+
+	roundTrip := h.roundTripper(h.client())
+	if h.retries == 0 || h.streamingBody() {
+		return h.send(roundTrip)
+	}
 
+	var response *http.Response
+	attempt := func() (err error) {
+		if h.attempts > 0 && h.request.GetBody != nil {
+			if h.request.Body, err = h.request.GetBody(); err != nil {
+				return retry.Unrecoverable(err)
+			}
+		}
+		response, err = h.send(roundTrip)
+		return h.checkAttempt(response, err, budget)
+	}
+	err = retry.Do(h.request.Context(), attempt,
+		retry.Attempts(int(h.retries)+1),
+		retry.WithBackoff(h.backoff),
+		retry.RetryIf(isFailedAttempt),
+		retry.OnRetry(func(attempt int, _ error, delay time.Duration) {
+			if attempt == 1 {
+				h.logger.Info("Starting retries", F("retries", h.retries))
+			}
+			if key := h.request.Header.Get(idempotencyKeyHeader); key != "" {
+				h.logger.Debug("Retrying idempotent request",
+					F("idempotency_key", key),
+					F("attempt", attempt+1),
+					F("delay", delay))
+			}
+		}),
+	)
+	if err != nil {
+		var exhausted *retry.Error
+		if errors.As(err, &exhausted) {
+			return nil, &RetryError{Attempts: h.attempts, StatusCode: h.lastStatus, Err: exhausted.Err.(*failedAttempt).err}
+		}
+		return nil, err
+	}
+	if h.streamResponse {
 		return response, nil
 	}
 
-	return nil, fmt.Errorf("Request failed")
+	responsePayload, err := ioutil.ReadAll(response.Body)
+	response.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	response.Body = newBufferedBody(responsePayload)
+
+	return response, nil
+}
+
+// send makes a single attempt through roundTrip, which do builds once per
+// call so that retries share it.
+func (h *httpRequest) send(roundTrip RoundTripFunc) (*http.Response, error) {
+	request := h.request
+	if h.on1xx != nil {
+		trace := &httptrace.ClientTrace{
+			Got1xxResponse: func(code int, header textproto.MIMEHeader) error {
+				h.on1xx(code, http.Header(header))
+				return nil
+			},
+		}
+		request = request.WithContext(httptrace.WithClientTrace(request.Context(), trace))
+	}
+
+	ctx, done, err := h.shutdownTracker().track(request.Context())
+	if err != nil {
+		return nil, err
+	}
+	h.attempts++
+	ctx = context.WithValue(ctx, attemptContextKey{}, h.attempts)
+	recordTimings := func() {}
+	if h.collectTimings {
+		ctx, recordTimings = h.traceTimings(ctx, h.attempts)
+	}
+	ctx, headersExpired := h.headersDeadline(ctx)
+	start := time.Now()
+	response, err := roundTrip(request.WithContext(ctx))
+	recordTimings()
+	timedOut := headersExpired()
+	if err != nil {
+		done()
+		h.lastStatus = 0
+		if timedOut {
+			err = &TimeoutError{Err: fmt.Errorf("No response headers within %v: %w", h.transportOptions.headersTimeout, err)}
+		} else if netError, ok := err.(net.Error); ok && netError.Timeout() {
+			err = &TimeoutError{Err: err}
+		}
+		if debugEnabled(h.logger) {
+			h.logger.Debug("Attempt failed",
+				F("method", request.Method),
+				F("url", redactedURL(request.URL)),
+				F("attempt", h.attempts),
+				F("duration", time.Since(start)),
+				F("error", err))
+		}
+		return response, err
+	}
+	h.lastStatus = response.StatusCode
+	if debugEnabled(h.logger) {
+		h.logger.Debug("Attempt finished",
+			F("method", request.Method),
+			F("url", redactedURL(request.URL)),
+			F("attempt", h.attempts),
+			F("duration", time.Since(start)),
+			F("status", response.StatusCode))
+	}
+	response.Body = trackedBody{response.Body, &h.bytesReceived, done}
+	return response, nil
+}
+
+type flightCall struct {
+	wg       sync.WaitGroup
+	response *http.Response
+	body     []byte
+	err      error
+}
+
+type flightGroup struct {
+	mu    sync.Mutex
+	calls map[string]*flightCall
+}
+
+var inFlight = &flightGroup{calls: make(map[string]*flightCall)}
+
+func (g *flightGroup) do(key string, fn func() (*http.Response, error)) (*http.Response, error) {
+	g.mu.Lock()
+	if call, ok := g.calls[key]; ok {
+		g.mu.Unlock()
+		call.wg.Wait()
+		return call.copyResponse()
+	}
+	call := &flightCall{}
+	call.wg.Add(1)
+	g.calls[key] = call
+	g.mu.Unlock()
+
+	call.response, call.err = fn()
+	if call.err == nil {
+		call.body, call.err = ioutil.ReadAll(call.response.Body)
+		call.response.Body.Close()
+	}
+	call.wg.Done()
+
+	g.mu.Lock()
+	delete(g.calls, key)
+	g.mu.Unlock()
+
+	return call.copyResponse()
+}
+
+func (c *flightCall) copyResponse() (*http.Response, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	response := *c.response
+	response.Header = c.response.Header.Clone()
+	response.Body = newBufferedBody(c.body)
+	return &response, nil
 }
@@ -0,0 +1,166 @@
+// Package requesttest provides transports for testing code that uses the
+// request package without starting a server. Install them with SetTransport.
+package requesttest
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+)
+
+// ErrNoRoute is returned for a request that no mock route or recorded
+// interaction matches.
+var ErrNoRoute = errors.New("No mock response matches request")
+
+// MockTransport is an http.RoundTripper that answers requests with scripted
+// responses. Routes are matched in the order they were added.
+type MockTransport struct {
+	mu     sync.Mutex
+	routes []*Route
+}
+
+// Route matches requests by method, path and headers, and holds the responses
+// returned for them.
+type Route struct {
+	mu        *sync.Mutex
+	method    string
+	path      string
+	header    http.Header
+	responses []response
+	calls     int
+}
+
+type response struct {
+	status int
+	header http.Header
+	body   []byte
+	err    error
+}
+
+// NewMockTransport returns a MockTransport with no routes.
+func NewMockTransport() *MockTransport {
+	return &MockTransport{}
+}
+
+// On adds a route for requests with the given method and URL path. An empty
+// method matches any method.
+func (m *MockTransport) On(method, path string) *Route {
+	route := &Route{mu: &m.mu, method: method, path: path, header: make(http.Header)}
+	m.mu.Lock()
+	m.routes = append(m.routes, route)
+	m.mu.Unlock()
+	return route
+}
+
+// MatchHeader restricts the route to requests carrying the header key with
+// value.
+func (r *Route) MatchHeader(key, value string) *Route {
+	r.header.Add(key, value)
+	return r
+}
+
+// Respond adds a response with status and body. Responses are returned in the
+// order they were added, one per call, and the last one repeats.
+func (r *Route) Respond(status int, body string) *Route {
+	r.responses = append(r.responses, response{status: status, header: make(http.Header), body: []byte(body)})
+	return r
+}
+
+// WithHeader sets a header on the response added last.
+func (r *Route) WithHeader(key, value string) *Route {
+	if len(r.responses) == 0 {
+		r.Respond(http.StatusOK, "")
+	}
+	r.responses[len(r.responses)-1].header.Add(key, value)
+	return r
+}
+
+// RespondError adds a response that fails with err, e.g. to simulate a
+// network failure.
+func (r *Route) RespondError(err error) *Route {
+	r.responses = append(r.responses, response{err: err})
+	return r
+}
+
+// Calls returns the number of requests the route has matched.
+func (r *Route) Calls() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.calls
+}
+
+func (r *Route) matches(req *http.Request) bool {
+	if r.method != "" && r.method != req.Method {
+		return false
+	}
+	if r.path != req.URL.Path {
+		return false
+	}
+	for key, values := range r.header {
+		got := req.Header[key]
+		for _, value := range values {
+			if !contains(got, value) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// RoundTrip implements http.RoundTripper.
+func (m *MockTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, route := range m.routes {
+		if !route.matches(req) {
+			continue
+		}
+		route.calls++
+		if len(route.responses) == 0 {
+			return newResponse(req, http.StatusOK, nil, nil), nil
+		}
+		i := route.calls - 1
+		if i >= len(route.responses) {
+			i = len(route.responses) - 1
+		}
+		scripted := route.responses[i]
+		if scripted.err != nil {
+			return nil, scripted.err
+		}
+		return newResponse(req, scripted.status, scripted.header, scripted.body), nil
+	}
+	return nil, fmt.Errorf("%w: %s %s", ErrNoRoute, req.Method, req.URL)
+}
+
+func newResponse(req *http.Request, status int, header http.Header, body []byte) *http.Response {
+	if header == nil {
+		header = make(http.Header)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header.Clone(),
+		Body:          ioutil.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
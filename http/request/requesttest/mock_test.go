@@ -0,0 +1,60 @@
+package requesttest
+
+import (
+	"errors"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/metamemelord/go-utilities/http/request"
+)
+
+func TestMockTransport(t *testing.T) {
+	mock := NewMockTransport()
+	admin := mock.On(http.MethodGet, "/users").MatchHeader("X-Role", "admin").
+		Respond(http.StatusOK, `["alice","bob"]`).WithHeader("Content-Type", "application/json")
+	users := mock.On(http.MethodGet, "/users").
+		Respond(http.StatusServiceUnavailable, "").
+		Respond(http.StatusOK, `["alice"]`)
+
+	response, err := request.Get("http://api.test/users").
+		SetTransport(mock).
+		SetRetries(1).
+		SetRetryPolicy(request.RetryOnStatus(http.StatusServiceUnavailable)).
+		SetBackoff(request.ConstantBackoff(time.Millisecond)).
+		SetLogger(request.StdLogger{Logger: log.New(ioutil.Discard, "", 0)}).
+		Do()
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := ioutil.ReadAll(response.Body)
+	response.Body.Close()
+	if string(body) != `["alice"]` || users.Calls() != 2 {
+		t.Errorf("got %q after %d calls, want the second scripted response", body, users.Calls())
+	}
+
+	response, err = request.Get("http://api.test/users").SetTransport(mock).SetHeader("X-Role", "admin").Do()
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ = ioutil.ReadAll(response.Body)
+	response.Body.Close()
+	if string(body) != `["alice","bob"]` || response.Header.Get("Content-Type") != "application/json" || admin.Calls() != 1 {
+		t.Errorf("header route returned %q with Content-Type %q", body, response.Header.Get("Content-Type"))
+	}
+}
+
+func TestMockTransportErrors(t *testing.T) {
+	failure := errors.New("connection reset")
+	mock := NewMockTransport()
+	mock.On("", "/flaky").RespondError(failure)
+
+	if _, err := request.Post("http://api.test/flaky").SetTransport(mock).Do(); !errors.Is(err, failure) {
+		t.Errorf("err = %v, want the scripted error", err)
+	}
+	if _, err := request.Get("http://api.test/missing").SetTransport(mock).Do(); !errors.Is(err, ErrNoRoute) {
+		t.Errorf("err = %v, want ErrNoRoute", err)
+	}
+}
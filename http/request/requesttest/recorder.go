@@ -0,0 +1,128 @@
+package requesttest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+)
+
+// Interaction is a recorded request and the response it received, as stored
+// in a golden file.
+type Interaction struct {
+	Method         string      `json:"method"`
+	URL            string      `json:"url"`
+	RequestBody    []byte      `json:"request_body,omitempty"`
+	StatusCode     int         `json:"status_code"`
+	ResponseHeader http.Header `json:"response_header,omitempty"`
+	ResponseBody   []byte      `json:"response_body,omitempty"`
+}
+
+// Recorder is an http.RoundTripper that forwards requests to a real
+// transport and records each exchange. Call Save to write the golden file.
+type Recorder struct {
+	path         string
+	next         http.RoundTripper
+	mu           sync.Mutex
+	interactions []Interaction
+}
+
+// NewRecorder returns a Recorder that sends requests through next, or
+// http.DefaultTransport if next is nil, and saves them to path.
+func NewRecorder(path string, next http.RoundTripper) *Recorder {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &Recorder{path: path, next: next}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var requestBody []byte
+	if req.Body != nil {
+		var err error
+		if requestBody, err = ioutil.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+		req = req.Clone(req.Context())
+		req.Body = ioutil.NopCloser(bytes.NewReader(requestBody))
+	}
+
+	response, err := r.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	responseBody, err := ioutil.ReadAll(response.Body)
+	response.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	response.Body = ioutil.NopCloser(bytes.NewReader(responseBody))
+
+	r.mu.Lock()
+	r.interactions = append(r.interactions, Interaction{
+		Method:         req.Method,
+		URL:            req.URL.String(),
+		RequestBody:    requestBody,
+		StatusCode:     response.StatusCode,
+		ResponseHeader: response.Header,
+		ResponseBody:   responseBody,
+	})
+	r.mu.Unlock()
+	return response, nil
+}
+
+// Save writes the interactions recorded so far to the golden file.
+func (r *Recorder) Save() error {
+	r.mu.Lock()
+	contents, err := json.MarshalIndent(r.interactions, "", "  ")
+	r.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(r.path, contents, 0644)
+}
+
+// Replayer is an http.RoundTripper that answers requests from a golden file
+// written by a Recorder, without network access. Requests are matched by
+// method and URL; repeated requests get the recorded responses in order.
+type Replayer struct {
+	mu           sync.Mutex
+	interactions []Interaction
+	used         []bool
+}
+
+// NewReplayer loads the golden file at path.
+func NewReplayer(path string) (*Replayer, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var interactions []Interaction
+	if err = json.Unmarshal(contents, &interactions); err != nil {
+		return nil, fmt.Errorf("Invalid golden file %s: %w", path, err)
+	}
+	return &Replayer{interactions: interactions, used: make([]bool, len(interactions))}, nil
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+
+	url := req.URL.String()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, interaction := range r.interactions {
+		if r.used[i] || interaction.Method != req.Method || interaction.URL != url {
+			continue
+		}
+		r.used[i] = true
+		return newResponse(req, interaction.StatusCode, interaction.ResponseHeader, interaction.ResponseBody), nil
+	}
+	return nil, fmt.Errorf("%w: %s %s", ErrNoRoute, req.Method, url)
+}
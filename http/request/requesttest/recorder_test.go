@@ -0,0 +1,68 @@
+package requesttest
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/metamemelord/go-utilities/http/request"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		body, _ := ioutil.ReadAll(r.Body)
+		w.Header().Set("X-Call", strconv.Itoa(calls))
+		w.Write(append([]byte(r.Method+" "), body...))
+	}))
+	path := filepath.Join(t.TempDir(), "golden.json")
+
+	recorder := NewRecorder(path, nil)
+	for _, builder := range []interface {
+		Do() (*http.Response, error)
+	}{
+		request.Get(server.URL + "/item").SetTransport(recorder),
+		request.Get(server.URL + "/item").SetTransport(recorder),
+		request.Post(server.URL + "/item").SetPayload([]byte("payload")).SetTransport(recorder),
+	} {
+		response, err := builder.Do()
+		if err != nil {
+			t.Fatal(err)
+		}
+		response.Body.Close()
+	}
+	if err := recorder.Save(); err != nil {
+		t.Fatal(err)
+	}
+	server.Close()
+
+	replayer, err := NewReplayer(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []struct{ method, body, call string }{
+		{http.MethodPost, "POST payload", "3"},
+		{http.MethodGet, "GET ", "1"},
+		{http.MethodGet, "GET ", "2"},
+	} {
+		response, err := request.Get(server.URL + "/item").SetMethod(want.method).SetTransport(replayer).Do()
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := ioutil.ReadAll(response.Body)
+		response.Body.Close()
+		if string(body) != want.body || response.Header.Get("X-Call") != want.call {
+			t.Errorf("replayed %s = %q from call %s, want %q from call %s",
+				want.method, body, response.Header.Get("X-Call"), want.body, want.call)
+		}
+	}
+
+	if _, err := request.Get(server.URL + "/item").SetTransport(replayer).Do(); !errors.Is(err, ErrNoRoute) {
+		t.Errorf("err = %v, want ErrNoRoute once the recordings are used up", err)
+	}
+}
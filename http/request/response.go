@@ -0,0 +1,135 @@
+package request
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+// Response wraps a fully read *http.Response with convenience accessors and
+// metadata about how it was obtained.
+type Response struct {
+	response *http.Response
+	body     []byte
+	attempts int
+	latency  time.Duration
+	timings  []Timings
+}
+
+// DoWrapped performs the request like Do, reads the whole response body and
+// returns it as a *Response. Gzip and deflate encoded bodies are decoded.
+func (h *httpRequest) DoWrapped() (*Response, error) {
+	if h.request.Header.Get("Accept-Encoding") == "" {
+		h.SetHeader("Accept-Encoding", "gzip, deflate")
+	}
+
+	start := time.Now()
+	response, err := h.Do()
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := ioutil.ReadAll(response.Body)
+	response.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	body, decoded, err := decompressBody(response.Header.Get("Content-Encoding"), body)
+	if err != nil {
+		return nil, err
+	}
+	if decoded {
+		response.Header.Del("Content-Encoding")
+		response.Header.Del("Content-Length")
+		response.ContentLength = int64(len(body))
+		response.Uncompressed = true
+	}
+	response.Body = newBufferedBody(body)
+
+	return &Response{
+		response: response,
+		body:     body,
+		attempts: h.attempts,
+		latency:  time.Since(start),
+		timings:  h.timings,
+	}, nil
+}
+
+// Raw returns the underlying *http.Response. Its body has already been read
+// and is replaced with an in-memory copy.
+func (r *Response) Raw() *http.Response {
+	return r.response
+}
+
+// Close closes the body of the raw response. The network body is always
+// fully read and closed before DoWrapped returns, so its connection is
+// already back in the pool; Close exists so callers can release every
+// response the same way, and may be called more than once.
+func (r *Response) Close() error {
+	return r.response.Body.Close()
+}
+
+func (r *Response) Bytes() []byte {
+	return r.body
+}
+
+func (r *Response) String() string {
+	return string(r.body)
+}
+
+// JSON decodes the response body into v.
+func (r *Response) JSON(v interface{}) error {
+	return json.Unmarshal(r.body, v)
+}
+
+// XML decodes the response body as XML into v.
+func (r *Response) XML(v interface{}) error {
+	return xml.Unmarshal(r.body, v)
+}
+
+func (r *Response) StatusCode() int {
+	return r.response.StatusCode
+}
+
+// statusError returns the *StatusError describing a non-2xx response.
+func (r *Response) statusError() error {
+	return &StatusError{StatusCode: r.response.StatusCode, Status: r.response.Status, Body: r.body}
+}
+
+// IsSuccess reports whether the status code is 2xx.
+func (r *Response) IsSuccess() bool {
+	return isSuccess(r.response.StatusCode)
+}
+
+// Header returns the first value of the response header key.
+func (r *Response) Header(key string) string {
+	return r.response.Header.Get(key)
+}
+
+// Attempts returns how many times the request was sent.
+func (r *Response) Attempts() int {
+	return r.attempts
+}
+
+// Retries returns how many attempts were made after the first one.
+func (r *Response) Retries() int {
+	if r.attempts == 0 {
+		return 0
+	}
+	return r.attempts - 1
+}
+
+// Latency returns the total time taken by the request, including retries
+// and reading the body.
+func (r *Response) Latency() time.Duration {
+	return r.latency
+}
+
+// Timings returns the breakdown of each attempt if timings were enabled with
+// SetTimings or OnTimings.
+func (r *Response) Timings() []Timings {
+	return r.timings
+}
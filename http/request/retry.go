@@ -0,0 +1,115 @@
+package request
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/metamemelord/go-utilities/retry"
+)
+
+// RetryPolicy reports whether an attempt that returned response and err
+// should be retried. Exactly one of response and err is non-nil.
+type RetryPolicy func(response *http.Response, err error) bool
+
+// RetryOnError retries attempts that failed with a transport error. It is the
+// default policy.
+func RetryOnError(response *http.Response, err error) bool {
+	return err != nil
+}
+
+// RetryOnStatus retries transport errors and responses with any of the given
+// status codes, e.g. RetryOnStatus(429, 502, 503, 504).
+func RetryOnStatus(codes ...int) RetryPolicy {
+	return func(response *http.Response, err error) bool {
+		if err != nil {
+			return true
+		}
+		for _, code := range codes {
+			if response.StatusCode == code {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// SetRetryPolicy sets which attempts are retried when retries are enabled.
+func (h *httpRequest) SetRetryPolicy(policy RetryPolicy) *httpRequest {
+	h.retryPolicy = policy
+	return h
+}
+
+func (h *httpRequest) shouldRetry(response *http.Response, err error) bool {
+	if h.retryPolicy == nil {
+		return RetryOnError(response, err)
+	}
+	return h.retryPolicy(response, err)
+}
+
+// failedAttempt is the error an attempt reports to the retry package when its
+// outcome should be retried. A failed response is already discarded.
+type failedAttempt struct {
+	err        error
+	status     string
+	retryAfter time.Duration
+}
+
+func (f *failedAttempt) Error() string {
+	if f.err != nil {
+		return f.err.Error()
+	}
+	return f.status
+}
+
+// RetryAfter makes the retry package wait as long as a 429 or 503 response
+// asked for.
+func (f *failedAttempt) RetryAfter() time.Duration {
+	return f.retryAfter
+}
+
+func isFailedAttempt(err error) bool {
+	_, ok := err.(*failedAttempt)
+	return ok
+}
+
+// checkAttempt turns the outcome of an attempt into the error handed to the
+// retry package: nil on success, the error itself if it should not be
+// retried, and a *failedAttempt otherwise. It logs failed attempts and stops
+// retrying once the retry budget is spent.
+func (h *httpRequest) checkAttempt(response *http.Response, err error, budget *RetryBudget) error {
+	if err == ErrCircuitOpen || err == ErrShutdown {
+		return retry.Unrecoverable(err)
+	}
+	if !h.shouldRetry(response, err) {
+		return err
+	}
+
+	failed := &failedAttempt{err: err, retryAfter: h.retryAfter(response)}
+	if err == nil {
+		h.logger.Error("Call failed", F("attempt", h.attempts), F("status", response.Status))
+		failed.status = response.Status
+		discardResponse(response)
+	} else if _, ok := err.(*TimeoutError); ok {
+		h.logger.Error("Request timed out", F("attempt", h.attempts))
+	} else {
+		h.logger.Error("Call failed", F("attempt", h.attempts), F("error", err))
+	}
+	if h.attempts <= int(h.retries) && budget != nil && !budget.withdraw() {
+		return retry.Unrecoverable(&RetryBudgetError{Attempts: h.attempts, StatusCode: h.lastStatus, Err: err})
+	}
+	return failed
+}
+
+// maxDrainBytes bounds how much of a discarded body is read to keep its
+// connection; larger bodies are closed right away, dropping the connection.
+const maxDrainBytes = 64 << 10
+
+// discardResponse drains and closes the body of a response that will not be
+// returned, such as one about to be retried, so its connection can be reused
+// for the next attempt.
+func discardResponse(response *http.Response) {
+	io.CopyN(ioutil.Discard, response.Body, maxDrainBytes)
+	response.Body.Close()
+}
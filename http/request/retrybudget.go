@@ -0,0 +1,106 @@
+package request
+
+import (
+	"sync"
+	"time"
+)
+
+const budgetBuckets = 10
+
+// RetryBudget caps retries at a fraction of the requests sent over a sliding
+// window, so retries cannot multiply traffic to an upstream that is already
+// failing. It is safe for concurrent use and is meant to be shared by every
+// request to the same upstream.
+type RetryBudget struct {
+	mu          sync.Mutex
+	ratio       float64
+	minRetries  int
+	bucketWidth int64
+	buckets     [budgetBuckets]budgetBucket
+}
+
+type budgetBucket struct {
+	epoch    int64
+	requests int
+	retries  int
+}
+
+// NewRetryBudget returns a budget that allows retries up to ratio times the
+// number of requests sent in the last window, e.g. 0.2 for 20%. minRetries
+// retries per window are always allowed so that low traffic can still retry.
+func NewRetryBudget(ratio float64, window time.Duration, minRetries int) *RetryBudget {
+	width := int64(window) / budgetBuckets
+	if width <= 0 {
+		width = 1
+	}
+	return &RetryBudget{ratio: ratio, minRetries: minRetries, bucketWidth: width}
+}
+
+var (
+	defaultRetryBudgetMutex sync.RWMutex
+	defaultRetryBudget      *RetryBudget
+)
+
+// SetDefaultRetryBudget sets the budget used by requests that do not set
+// their own with SetRetryBudget. Nil removes it.
+func SetDefaultRetryBudget(budget *RetryBudget) {
+	defaultRetryBudgetMutex.Lock()
+	defer defaultRetryBudgetMutex.Unlock()
+	defaultRetryBudget = budget
+}
+
+// SetRetryBudget draws the request's retries from budget. Once the budget is
+// spent, failed attempts are returned as a *RetryBudgetError instead of being
+// retried.
+func (h *httpRequest) SetRetryBudget(budget *RetryBudget) *httpRequest {
+	h.retryBudget = budget
+	return h
+}
+
+func (h *httpRequest) budget() *RetryBudget {
+	if h.retryBudget != nil {
+		return h.retryBudget
+	}
+	defaultRetryBudgetMutex.RLock()
+	defer defaultRetryBudgetMutex.RUnlock()
+	return defaultRetryBudget
+}
+
+// bucket returns the bucket for now, clearing it if it belongs to an
+// earlier window.
+func (b *RetryBudget) bucket(now time.Time) *budgetBucket {
+	epoch := now.UnixNano() / b.bucketWidth
+	bucket := &b.buckets[epoch%budgetBuckets]
+	if bucket.epoch != epoch {
+		*bucket = budgetBucket{epoch: epoch}
+	}
+	return bucket
+}
+
+func (b *RetryBudget) recordRequest() {
+	b.mu.Lock()
+	b.bucket(time.Now()).requests++
+	b.mu.Unlock()
+}
+
+// withdraw reports whether a retry is allowed, recording it if so.
+func (b *RetryBudget) withdraw() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	current := b.bucket(now)
+	oldest := current.epoch - budgetBuckets + 1
+	requests, retries := 0, 0
+	for _, bucket := range b.buckets {
+		if bucket.epoch >= oldest {
+			requests += bucket.requests
+			retries += bucket.retries
+		}
+	}
+	if retries >= b.minRetries && float64(retries+1) > b.ratio*float64(requests) {
+		return false
+	}
+	current.retries++
+	return true
+}
@@ -0,0 +1,135 @@
+package request
+
+import (
+	"context"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// requestTracker tracks the cancel function of every request that has been sent
+// and whose response body has not been closed yet. Once shut down, it refuses
+// to track, and so to send, further requests.
+type requestTracker struct {
+	mu      sync.Mutex
+	nextID  uint64
+	closed  bool
+	cancels map[uint64]context.CancelFunc
+}
+
+func newRequestTracker() *requestTracker {
+	return &requestTracker{cancels: make(map[uint64]context.CancelFunc)}
+}
+
+// tracker tracks the requests not made through a Client.
+var tracker = newRequestTracker()
+
+func (t *requestTracker) track(ctx context.Context) (context.Context, func(), error) {
+	t.mu.Lock()
+	if t.closed {
+		t.mu.Unlock()
+		return nil, nil, ErrShutdown
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	id := t.nextID
+	t.nextID++
+	t.cancels[id] = cancel
+	t.mu.Unlock()
+
+	return ctx, func() {
+		t.mu.Lock()
+		delete(t.cancels, id)
+		t.mu.Unlock()
+		cancel()
+	}, nil
+}
+
+func (t *requestTracker) isClosed() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.closed
+}
+
+func (t *requestTracker) cancelAll() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.closed = true
+	for _, cancel := range t.cancels {
+		cancel()
+	}
+}
+
+func (t *requestTracker) len() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.cancels)
+}
+
+// trackedBody counts the bytes read from a response body and stops tracking
+// the request once the body is closed.
+type trackedBody struct {
+	io.ReadCloser
+	count *int64
+	done  func()
+}
+
+func (b trackedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	atomic.AddInt64(b.count, int64(n))
+	return n, err
+}
+
+func (b trackedBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.done()
+	return err
+}
+
+// shutdown cancels the tracked requests, refusing new ones, closes idle
+// connections and waits for the cancelled requests to finish.
+func (t *requestTracker) shutdown(ctx context.Context) error {
+	t.cancelAll()
+	transports.closeIdleConnections()
+
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+	for t.len() > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
+
+// Shutdown cancels every in-flight request made through this package but not
+// through a Client, which has its own Shutdown, closes idle connections and
+// blocks until all cancelled requests have finished or ctx expires. A request
+// stays in flight until its response body is closed. Requests sent afterwards
+// fail with ErrShutdown.
+func Shutdown(ctx context.Context) error {
+	return tracker.shutdown(ctx)
+}
+
+// shutdownTracker returns the tracker the request belongs to.
+func (h *httpRequest) shutdownTracker() *requestTracker {
+	if h.tracker != nil {
+		return h.tracker
+	}
+	return tracker
+}
+
+// cancelOnClose releases a context derived for a call once the response body
+// is closed.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
@@ -0,0 +1,87 @@
+package request
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func slowServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			<-r.Context().Done()
+		}
+	}))
+}
+
+func waitInFlight(t *testing.T, tracker *requestTracker) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for tracker.len() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("request never started")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestClientShutdown(t *testing.T) {
+	server := slowServer()
+	defer server.Close()
+	client := NewClient(map[string]Profile{"svc": {BaseURL: server.URL}})
+
+	errs := make(chan error, 1)
+	go func() {
+		_, err := client.For("svc").Get("/slow").SetTimeout(0).Do()
+		errs <- err
+	}()
+	waitInFlight(t, client.tracker)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := client.Shutdown(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-errs; !errors.Is(err, context.Canceled) {
+		t.Errorf("in-flight request err = %v, want context.Canceled", err)
+	}
+
+	if _, err := client.For("svc").Get("/").Do(); err != ErrShutdown {
+		t.Errorf("request after Shutdown err = %v, want ErrShutdown", err)
+	}
+	response, err := Get(server.URL).Do()
+	if err != nil {
+		t.Fatalf("request outside the client failed: %v", err)
+	}
+	response.Body.Close()
+}
+
+func TestShutdown(t *testing.T) {
+	defer func(previous *requestTracker) { tracker = previous }(tracker)
+	tracker = newRequestTracker()
+
+	server := slowServer()
+	defer server.Close()
+
+	errs := make(chan error, 1)
+	go func() {
+		_, err := Get(server.URL + "/slow").SetTimeout(0).Do()
+		errs <- err
+	}()
+	waitInFlight(t, tracker)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := Shutdown(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-errs; !errors.Is(err, context.Canceled) {
+		t.Errorf("in-flight request err = %v, want context.Canceled", err)
+	}
+	if _, err := Get(server.URL).Do(); err != ErrShutdown {
+		t.Errorf("request after Shutdown err = %v, want ErrShutdown", err)
+	}
+}
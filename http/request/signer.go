@@ -0,0 +1,73 @@
+package request
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Signer adds a signature to an attempt just before it is sent. body holds
+// the body bytes as sent, an empty slice if there is no body, or nil if the
+// body is streamed from a reader, file or channel and not known in advance.
+type Signer func(request *http.Request, body []byte) error
+
+// SetSigner signs every attempt, including retries, with signer after the
+// request is fully built. It replaces any signer set before, including the
+// one set by SetAWSSigV4.
+func (h *httpRequest) SetSigner(signer Signer) *httpRequest {
+	h.signer = signer
+	return h
+}
+
+// HMACSigner returns a Signer that sets signatureHeader to the hex-encoded
+// HMAC-SHA256 of the body keyed with secret. If timestampHeader is not empty,
+// it is set to the current Unix time in seconds and the signed message is
+// the timestamp, a '.', then the body, so the server can reject replays.
+func HMACSigner(secret []byte, signatureHeader, timestampHeader string) Signer {
+	return func(request *http.Request, body []byte) error {
+		if body == nil {
+			return errors.New("HMAC signatures need a buffered body")
+		}
+		mac := hmac.New(sha256.New, secret)
+		if timestampHeader != "" {
+			timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+			request.Header.Set(timestampHeader, timestamp)
+			mac.Write([]byte(timestamp + "."))
+		}
+		mac.Write(body)
+		request.Header.Set(signatureHeader, hex.EncodeToString(mac.Sum(nil)))
+		return nil
+	}
+}
+
+// sign applies the request signer to every attempt just before it is sent,
+// on a copy of the request so retries are signed afresh.
+func (h *httpRequest) sign(roundTrip RoundTripFunc) RoundTripFunc {
+	if h.signer == nil {
+		return roundTrip
+	}
+	signer := h.signer
+
+	return func(request *http.Request) (*http.Response, error) {
+		signed := request.Clone(request.Context())
+		if err := signer(signed, h.signedBody(request)); err != nil {
+			closeBody(request)
+			return nil, fmt.Errorf("Could not sign request: %w", err)
+		}
+		return roundTrip(signed)
+	}
+}
+
+// signedBody returns the body bytes of request as sent, an empty slice if it
+// has no body, or nil if the body is streamed and not known in advance.
+func (h *httpRequest) signedBody(request *http.Request) []byte {
+	if request.Body == nil || request.Body == http.NoBody {
+		return []byte{}
+	}
+	return h.wireBody
+}
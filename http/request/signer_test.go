@@ -0,0 +1,24 @@
+package request
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestSignerClosesBodyWhenSigningFails(t *testing.T) {
+	failing := func(*http.Request, []byte) error { return errors.New("no credentials") }
+	next := func(*http.Request) (*http.Response, error) {
+		t.Error("unsigned request was sent")
+		return nil, nil
+	}
+	roundTrip := Post("http://signer-body.test").SetSigner(failing).sign(next)
+
+	request, body := newBodyRequest(t, "http://signer-body.test")
+	if _, err := roundTrip(request); err == nil {
+		t.Fatal("signing error was not returned")
+	}
+	if !body.closed {
+		t.Error("body of the unsigned request was not closed")
+	}
+}
@@ -0,0 +1,78 @@
+package request
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingServer counts the requests it serves, holding each one briefly so
+// concurrent callers overlap.
+func countingServer(calls *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(calls, 1)
+		time.Sleep(200 * time.Millisecond)
+		w.Write([]byte("shared"))
+	}))
+}
+
+func fireConcurrently(t *testing.T, n int, build func(i int) *httpRequest) {
+	t.Helper()
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			response, err := build(i).EnableSingleflight().Do()
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			body, _ := ioutil.ReadAll(response.Body)
+			response.Body.Close()
+			if string(body) != "shared" {
+				t.Errorf("body = %q, want %q", body, "shared")
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestSingleflight(t *testing.T) {
+	var calls int32
+	server := countingServer(&calls)
+	defer server.Close()
+
+	fireConcurrently(t, 10, func(int) *httpRequest { return Get(server.URL) })
+	if calls != 1 {
+		t.Errorf("server saw %d requests, want 1", calls)
+	}
+}
+
+func TestSingleflightKeepsDistinctHeadersApart(t *testing.T) {
+	var calls int32
+	server := countingServer(&calls)
+	defer server.Close()
+
+	fireConcurrently(t, 4, func(i int) *httpRequest {
+		return Get(server.URL).SetHeader("Accept-Language", []string{"en", "fr"}[i%2])
+	})
+	if calls != 2 {
+		t.Errorf("server saw %d requests, want 2", calls)
+	}
+}
+
+func TestSingleflightSkipsCredentials(t *testing.T) {
+	var calls int32
+	server := countingServer(&calls)
+	defer server.Close()
+
+	fireConcurrently(t, 3, func(int) *httpRequest { return Get(server.URL).SetBearerToken("token") })
+	if calls != 3 {
+		t.Errorf("server saw %d requests, want 3", calls)
+	}
+}
@@ -0,0 +1,141 @@
+package request
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultSSERetry is the reconnection delay used until the server sends one.
+const defaultSSERetry = 3 * time.Second
+
+// Event is a server-sent event.
+type Event struct {
+	// ID is the last event ID the server sent, which is also sent back in
+	// Last-Event-ID on reconnection.
+	ID string
+	// Event is the event type, "message" unless the server set one.
+	Event string
+	Data  string
+	// Retry is the reconnection delay the server asked for with this event,
+	// or zero.
+	Retry time.Duration
+}
+
+// DoSSE subscribes to a text/event-stream and calls handler for every event
+// until the server ends the stream with a 204 response or the request context
+// is canceled. When the connection drops, it reconnects after the delay last
+// requested by the server, sending Last-Event-ID so the stream resumes. The
+// attempt timeout does not apply; use the request context to bound the
+// subscription. A failure to connect the first time or a non-2xx response is
+// returned as an error.
+func (h *httpRequest) DoSSE(handler func(Event)) error {
+	h.SetHeader("Accept", "text/event-stream")
+	h.SetHeader("Cache-Control", "no-cache")
+
+	timeout, streamResponse := h.timeout, h.streamResponse
+	h.timeout, h.streamResponse = 0, true
+	defer func() { h.timeout, h.streamResponse = timeout, streamResponse }()
+
+	stream := &sseStream{retry: defaultSSERetry}
+	for connected := false; ; connected = true {
+		if stream.lastEventID != "" {
+			h.SetHeader("Last-Event-ID", stream.lastEventID)
+		}
+
+		response, err := h.Do()
+		if err != nil {
+			if !connected || h.request.Context().Err() != nil {
+				return err
+			}
+			h.logger.Error("Event stream reconnection failed", F("error", err))
+		} else {
+			if response.StatusCode == http.StatusNoContent {
+				response.Body.Close()
+				return nil
+			}
+			if !isSuccess(response.StatusCode) {
+				defer response.Body.Close()
+				return newStatusError(response)
+			}
+			err = stream.read(response.Body, handler)
+			response.Body.Close()
+			if ctxErr := h.request.Context().Err(); ctxErr != nil {
+				return ctxErr
+			}
+			h.logger.Info("Event stream disconnected", F("error", err))
+		}
+
+		timer := time.NewTimer(stream.retry)
+		select {
+		case <-h.request.Context().Done():
+			timer.Stop()
+			return h.request.Context().Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// sseStream holds the parser state that survives reconnections.
+type sseStream struct {
+	lastEventID string
+	retry       time.Duration
+}
+
+// read parses events from body until it ends.
+func (s *sseStream) read(body io.Reader, handler func(Event)) error {
+	reader := bufio.NewReader(body)
+	var event Event
+	var data strings.Builder
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		line = strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+
+		if line == "" {
+			if data.Len() > 0 {
+				event.ID = s.lastEventID
+				event.Data = strings.TrimSuffix(data.String(), "\n")
+				if event.Event == "" {
+					event.Event = "message"
+				}
+				handler(event)
+			}
+			event = Event{}
+			data.Reset()
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+
+		field, value := line, ""
+		if i := strings.IndexByte(line, ':'); i >= 0 {
+			field, value = line[:i], strings.TrimPrefix(line[i+1:], " ")
+		}
+		switch field {
+		case "event":
+			event.Event = value
+		case "data":
+			data.WriteString(value)
+			data.WriteByte('\n')
+		case "id":
+			if !strings.ContainsRune(value, 0) {
+				s.lastEventID = value
+			}
+		case "retry":
+			if ms, err := strconv.Atoi(value); err == nil && ms >= 0 {
+				s.retry = time.Duration(ms) * time.Millisecond
+				event.Retry = s.retry
+			}
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package request
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSSRFProtectionBlocksLoopback(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	_, err := Get(server.URL).SetSSRFProtection(SSRFOptions{}).Do()
+	if !errors.Is(err, ErrBlockedDestination) {
+		t.Errorf("err = %v, want ErrBlockedDestination", err)
+	}
+
+	response, err := Get(server.URL).SetSSRFProtection(SSRFOptions{AllowedNetworks: []string{"127.0.0.0/8"}}).Do()
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+}
+
+func TestSSRFProtectionRejectsProxy(t *testing.T) {
+	for name, builder := range map[string]*httpRequest{
+		"proxy after":  Get("http://example.com").SetSSRFProtection(SSRFOptions{}).SetProxy("http://proxy:3128"),
+		"proxy before": Get("http://example.com").SetProxy("http://proxy:3128").SetSSRFProtection(SSRFOptions{}),
+	} {
+		if err := builder.DryRun(); err == nil {
+			t.Errorf("%s: combining SSRF protection with a proxy was not reported", name)
+		}
+	}
+}
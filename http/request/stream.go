@@ -0,0 +1,114 @@
+package request
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"go.uber.org/multierr"
+)
+
+type channelReader struct {
+	ctx    context.Context
+	chunks <-chan []byte
+	chunk  []byte
+}
+
+func (r *channelReader) Read(p []byte) (int, error) {
+	for len(r.chunk) == 0 {
+		select {
+		case chunk, ok := <-r.chunks:
+			if !ok {
+				return 0, io.EOF
+			}
+			r.chunk = chunk
+		case <-r.ctx.Done():
+			return 0, r.ctx.Err()
+		}
+	}
+	n := copy(p, r.chunk)
+	r.chunk = r.chunk[n:]
+	return n, nil
+}
+
+func (r *channelReader) Close() error { return nil }
+
+// SetBodyFromChannel streams the chunks received from ch as the request body
+// until ch is closed. The body is not buffered, so retries are disabled, and
+// the stream is abandoned if the request context is cancelled.
+func (h *httpRequest) SetBodyFromChannel(ch <-chan []byte) *httpRequest {
+	h.resetBody()
+	h.bodyChannel = ch
+	return h
+}
+
+// SetPayloadFromFile streams the file at path as the request body. The file
+// is reopened for every attempt, so retries and redirects resend it without
+// holding it in memory. A missing file is reported by Do.
+func (h *httpRequest) SetPayloadFromFile(path string) *httpRequest {
+	info, err := os.Stat(path)
+	if err != nil {
+		h.err = multierr.Append(h.err, fmt.Errorf("Could not read payload file: %w", err))
+		return h
+	}
+	return h.SetRewindablePayload(func() (io.ReadCloser, error) {
+		return os.Open(path)
+	}, info.Size())
+}
+
+// SetRewindablePayload streams the reader returned by getBody as the request
+// body. getBody is called again for every retry or redirect instead of
+// buffering the body, so it must return a reader positioned at the start.
+// size is the body length in bytes, or -1 if unknown.
+func (h *httpRequest) SetRewindablePayload(getBody func() (io.ReadCloser, error), size int64) *httpRequest {
+	h.resetBody()
+	h.bodySource = getBody
+	h.bodySize = size
+	return h
+}
+
+// openBodySource installs a fresh reader from the body source as the request
+// body, along with a GetBody that opens another.
+func (h *httpRequest) openBodySource() error {
+	source := h.bodySource
+	getBody := func() (io.ReadCloser, error) {
+		body, err := source()
+		if err != nil {
+			return nil, err
+		}
+		return countingReadCloser{body, &h.bytesSent}, nil
+	}
+
+	body, err := getBody()
+	if err != nil {
+		return err
+	}
+	h.wireBody = nil
+	h.request.Body = body
+	h.request.GetBody = getBody
+	h.request.ContentLength = h.bodySize
+	if h.bodySize < 0 {
+		h.request.ContentLength = 0
+	}
+	return nil
+}
+
+// resetBody discards any payload, reader, channel, body source or form parts
+// set so far.
+func (h *httpRequest) resetBody() {
+	h.payload = nil
+	h.bodyChannel = nil
+	h.formParts = nil
+	h.bodySource = nil
+	h.wireBody = nil
+	h.request.Body = nil
+	h.request.GetBody = nil
+	h.request.ContentLength = 0
+}
+
+// streamingBody reports whether the body is produced while the request is
+// sent, in which case it is neither buffered nor retried.
+func (h *httpRequest) streamingBody() bool {
+	return h.bodyChannel != nil || len(h.formParts) > 0
+}
@@ -0,0 +1,72 @@
+package request
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSetBodyFromChannel(t *testing.T) {
+	var calls int32
+	var received string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		body, _ := ioutil.ReadAll(r.Body)
+		received = string(body)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	chunks := make(chan []byte)
+	go func() {
+		for _, chunk := range []string{"first ", "second ", "third"} {
+			chunks <- []byte(chunk)
+		}
+		close(chunks)
+	}()
+	response, err := Post(server.URL).
+		SetBodyFromChannel(chunks).
+		SetRetries(2).
+		SetRetryPolicy(RetryOnStatus(http.StatusServiceUnavailable)).
+		Do()
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+	if received != "first second third" {
+		t.Errorf("server received %q", received)
+	}
+	if calls != 1 {
+		t.Errorf("streamed body was sent %d times, want once", calls)
+	}
+}
+
+func TestSetBodyFromChannelCancelled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ioutil.ReadAll(r.Body)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	chunks := make(chan []byte, 1)
+	chunks <- []byte("never finished")
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := Post(server.URL).SetContext(ctx).SetBodyFromChannel(chunks).Do()
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Error("request with an abandoned stream succeeded")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("request did not give up on the stream when its context was cancelled")
+	}
+}
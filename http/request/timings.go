@@ -0,0 +1,126 @@
+package request
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// Timings breaks down where the time of one attempt went. Phases that did not
+// happen, such as DNS and connecting on a reused connection, are zero.
+type Timings struct {
+	// Attempt is the attempt number, starting at 1.
+	Attempt int
+	// ReusedConnection reports whether an idle connection was reused.
+	ReusedConnection bool
+	DNS              time.Duration
+	Connect          time.Duration
+	TLSHandshake     time.Duration
+	// TimeToFirstByte runs from the start of the attempt to the first byte
+	// of the response.
+	TimeToFirstByte time.Duration
+	// Total runs from the start of the attempt until the response headers
+	// were read.
+	Total time.Duration
+}
+
+// SetTimings records a Timings breakdown for every attempt, available from
+// Timings and from the wrapped response.
+func (h *httpRequest) SetTimings(enabled bool) *httpRequest {
+	h.collectTimings = enabled
+	return h
+}
+
+// OnTimings calls fn with the timing breakdown of every attempt once its
+// response headers arrive or it fails. It implies SetTimings(true).
+func (h *httpRequest) OnTimings(fn func(Timings)) *httpRequest {
+	h.onTimings = fn
+	return h.SetTimings(true)
+}
+
+// Timings returns the breakdown of each attempt of the last Do when timings
+// are enabled.
+func (h *httpRequest) Timings() []Timings {
+	return h.timings
+}
+
+// attemptTimer collects the httptrace events of one attempt. Hooks may run on
+// other goroutines, e.g. while dialing.
+type attemptTimer struct {
+	mu                     sync.Mutex
+	start                  time.Time
+	dnsStart, connectStart time.Time
+	tlsStart               time.Time
+	timings                Timings
+}
+
+func (h *httpRequest) traceTimings(ctx context.Context, attempt int) (context.Context, func()) {
+	t := &attemptTimer{start: time.Now(), timings: Timings{Attempt: attempt}}
+	since := func(from time.Time) time.Duration {
+		if from.IsZero() {
+			return 0
+		}
+		return time.Since(from)
+	}
+
+	ctx = httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) {
+			t.mu.Lock()
+			t.dnsStart = time.Now()
+			t.mu.Unlock()
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			t.mu.Lock()
+			t.timings.DNS = since(t.dnsStart)
+			t.mu.Unlock()
+		},
+		ConnectStart: func(string, string) {
+			t.mu.Lock()
+			if t.connectStart.IsZero() {
+				t.connectStart = time.Now()
+			}
+			t.mu.Unlock()
+		},
+		ConnectDone: func(_, _ string, err error) {
+			t.mu.Lock()
+			if err == nil {
+				t.timings.Connect = since(t.connectStart)
+			}
+			t.mu.Unlock()
+		},
+		TLSHandshakeStart: func() {
+			t.mu.Lock()
+			t.tlsStart = time.Now()
+			t.mu.Unlock()
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			t.mu.Lock()
+			t.timings.TLSHandshake = since(t.tlsStart)
+			t.mu.Unlock()
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			t.mu.Lock()
+			t.timings.ReusedConnection = info.Reused
+			t.mu.Unlock()
+		},
+		GotFirstResponseByte: func() {
+			t.mu.Lock()
+			t.timings.TimeToFirstByte = since(t.start)
+			t.mu.Unlock()
+		},
+	})
+
+	return ctx, func() {
+		t.mu.Lock()
+		t.timings.Total = since(t.start)
+		timings := t.timings
+		t.mu.Unlock()
+
+		h.timings = append(h.timings, timings)
+		if h.onTimings != nil {
+			h.onTimings(timings)
+		}
+	}
+}
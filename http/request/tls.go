@@ -0,0 +1,127 @@
+package request
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+
+	"go.uber.org/multierr"
+)
+
+// buildTLSConfig composes the TLS options of the request into the config of
+// a new transport. It returns nil when none is set.
+func (h *httpRequest) buildTLSConfig() *tls.Config {
+	options := h.transportOptions
+	if options.tlsConfig == nil && options.rootCAs == nil && options.trustedCAs == "" &&
+		!options.insecureSet && h.clientCertificate == nil {
+		return nil
+	}
+
+	config := &tls.Config{}
+	if options.tlsConfig != nil {
+		config = options.tlsConfig.Clone()
+	}
+	if options.rootCAs != nil {
+		config.RootCAs = options.rootCAs
+	}
+	if options.trustedCAs != "" {
+		// Never add to a pool owned by the caller. CertPool.Clone is why the
+		// module requires Go 1.19.
+		var pool *x509.CertPool
+		if config.RootCAs != nil {
+			pool = config.RootCAs.Clone()
+		} else if pool, _ = x509.SystemCertPool(); pool == nil {
+			pool = x509.NewCertPool()
+		}
+		pool.AppendCertsFromPEM([]byte(options.trustedCAs))
+		config.RootCAs = pool
+	}
+	if options.insecureSet {
+		config.InsecureSkipVerify = options.insecure
+	}
+	if h.clientCertificate != nil {
+		config.Certificates = []tls.Certificate{*h.clientCertificate}
+	}
+	return config
+}
+
+// SetTLSConfig uses a copy of config for TLS connections, replacing the TLS
+// options set so far. Options applied afterwards modify the copy, never config
+// itself. Requests sharing the same config share connections.
+func (h *httpRequest) SetTLSConfig(config *tls.Config) *httpRequest {
+	h.transportOptions.tlsConfig = config.Clone()
+	h.transportOptions.rootCAs = nil
+	h.transportOptions.trustedCAs = ""
+	h.transportOptions.insecure = false
+	h.transportOptions.insecureSet = false
+	h.transportOptions.clientCert = ""
+	h.clientCertificate = nil
+	return h
+}
+
+// SetRootCAs verifies servers against pool instead of the system roots.
+func (h *httpRequest) SetRootCAs(pool *x509.CertPool) *httpRequest {
+	h.transportOptions.rootCAs = pool
+	return h
+}
+
+// SetInsecureSkipVerify disables verification of the server certificate
+// chain and host name. Only use it for testing.
+func (h *httpRequest) SetInsecureSkipVerify(skip bool) *httpRequest {
+	h.transportOptions.insecure = skip
+	h.transportOptions.insecureSet = true
+	return h
+}
+
+// AddTrustedCA trusts the PEM encoded CA certificates in pemBytes in addition
+// to the system roots. Invalid data is reported by Do.
+func (h *httpRequest) AddTrustedCA(pemBytes []byte) *httpRequest {
+	if !x509.NewCertPool().AppendCertsFromPEM(pemBytes) {
+		h.err = multierr.Append(h.err, fmt.Errorf("No valid CA certificates found in PEM data"))
+		return h
+	}
+	h.transportOptions.trustedCAs += string(pemBytes) + "\n"
+	return h
+}
+
+// AddTrustedCAFile is like AddTrustedCA but reads the PEM data from path.
+func (h *httpRequest) AddTrustedCAFile(path string) *httpRequest {
+	pemBytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		h.err = multierr.Append(h.err, fmt.Errorf("Could not read CA file %s: %v", path, err))
+		return h
+	}
+	return h.AddTrustedCA(pemBytes)
+}
+
+// SetClientCertificate loads a PEM encoded certificate and private key from
+// certFile and keyFile and presents them for mutual TLS.
+func (h *httpRequest) SetClientCertificate(certFile, keyFile string) *httpRequest {
+	certificate, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		h.err = multierr.Append(h.err, fmt.Errorf("Could not load client certificate %s: %v", certFile, err))
+		return h
+	}
+	return h.setClientCertificate(certificate)
+}
+
+// SetClientCertificatePEM is like SetClientCertificate but takes the PEM
+// encoded certificate and private key directly.
+func (h *httpRequest) SetClientCertificatePEM(certPEM, keyPEM []byte) *httpRequest {
+	certificate, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		h.err = multierr.Append(h.err, fmt.Errorf("Could not load client certificate: %v", err))
+		return h
+	}
+	return h.setClientCertificate(certificate)
+}
+
+func (h *httpRequest) setClientCertificate(certificate tls.Certificate) *httpRequest {
+	fingerprint := sha256.Sum256(certificate.Certificate[0])
+	h.transportOptions.clientCert = hex.EncodeToString(fingerprint[:])
+	h.clientCertificate = &certificate
+	return h
+}
@@ -0,0 +1,136 @@
+package request
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"log"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func serverCAPEM(server *httptest.Server) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+}
+
+func TestAddTrustedCA(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	if _, err := Get(server.URL).SetRetries(0).Do(); err == nil {
+		t.Fatal("request to a server with an untrusted certificate succeeded")
+	}
+
+	response, err := Get(server.URL).AddTrustedCA(serverCAPEM(server)).Do()
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+}
+
+func TestAddTrustedCALeavesRootCAsUntouched(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	pool := x509.NewCertPool()
+	response, err := Get(server.URL).SetRootCAs(pool).AddTrustedCA(serverCAPEM(server)).Do()
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+	if !pool.Equal(x509.NewCertPool()) {
+		t.Error("AddTrustedCA added to the pool passed to SetRootCAs")
+	}
+}
+
+func TestAddTrustedCAInvalidPEM(t *testing.T) {
+	if _, err := Get("https://example.com").AddTrustedCA([]byte("not a certificate")).Do(); err == nil {
+		t.Error("invalid PEM data was not reported")
+	}
+}
+
+// clientCertificatePEM returns a self-signed client certificate for commonName
+// and its private key, PEM encoded.
+func clientCertificatePEM(t *testing.T, commonName string) (certPEM, keyPEM []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		IsCA:         true,
+
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func TestSetClientCertificate(t *testing.T) {
+	certPEM, keyPEM := clientCertificatePEM(t, "client")
+	clientCAs := x509.NewCertPool()
+	clientCAs.AppendCertsFromPEM(certPEM)
+
+	var peer string
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		peer = r.TLS.PeerCertificates[0].Subject.CommonName
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+	server.Config.ErrorLog = log.New(ioutil.Discard, "", 0)
+	server.StartTLS()
+	defer server.Close()
+
+	if _, err := Get(server.URL).AddTrustedCA(serverCAPEM(server)).Do(); err == nil {
+		t.Fatal("request without a client certificate succeeded")
+	}
+
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "client.crt"), filepath.Join(dir, "client.key")
+	if err := ioutil.WriteFile(certFile, certPEM, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(keyFile, keyPEM, 0600); err != nil {
+		t.Fatal(err)
+	}
+	for name, builder := range map[string]*httpRequest{
+		"PEM":   Get(server.URL).AddTrustedCA(serverCAPEM(server)).SetClientCertificatePEM(certPEM, keyPEM),
+		"files": Get(server.URL).AddTrustedCA(serverCAPEM(server)).SetClientCertificate(certFile, keyFile),
+	} {
+		peer = ""
+		response, err := builder.Do()
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		response.Body.Close()
+		if peer != "client" {
+			t.Errorf("%s: server saw client certificate %q", name, peer)
+		}
+	}
+
+	if _, err := Get(server.URL).SetClientCertificate(keyFile, certFile).Do(); err == nil {
+		t.Error("swapped certificate and key files were accepted")
+	}
+}
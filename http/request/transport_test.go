@@ -0,0 +1,219 @@
+package request
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTransportOptionsShareTransport(t *testing.T) {
+	a := Get("http://example.com").SetReadBufferSize(64 << 10).SetWriteBufferSize(32 << 10)
+	b := Get("http://example.org").SetReadBufferSize(64 << 10).SetWriteBufferSize(32 << 10)
+	c := Get("http://example.com").SetReadBufferSize(16 << 10)
+
+	transport := a.client().Transport.(*http.Transport)
+	if transport.ReadBufferSize != 64<<10 || transport.WriteBufferSize != 32<<10 {
+		t.Errorf("buffer sizes = %d, %d; want %d, %d", transport.ReadBufferSize, transport.WriteBufferSize, 64<<10, 32<<10)
+	}
+	if b.client().Transport != transport {
+		t.Error("requests with equal options use different transports")
+	}
+	if c.client().Transport == transport {
+		t.Error("requests with different options share a transport")
+	}
+}
+
+func TestTransportOptionsPoolConnections(t *testing.T) {
+	var conns int
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			conns++
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	for i := 0; i < 5; i++ {
+		response, err := Get(server.URL).SetHeadersTimeout(time.Second).Do()
+		if err != nil {
+			t.Fatal(err)
+		}
+		response.Body.Close()
+	}
+	if conns != 1 {
+		t.Errorf("opened %d connections, want 1", conns)
+	}
+}
+
+func TestSetMaxConnsPerHostReplacesTransports(t *testing.T) {
+	defer SetMaxConnsPerHost(0)
+
+	before := Get("http://example.com").SetReadBufferSize(8 << 10).client().Transport
+	SetMaxConnsPerHost(3)
+	after := Get("http://example.com").SetReadBufferSize(8 << 10).client().Transport.(*http.Transport)
+	if after == before {
+		t.Fatal("transport was not replaced")
+	}
+	if after.MaxConnsPerHost != 3 {
+		t.Errorf("MaxConnsPerHost = %d, want 3", after.MaxConnsPerHost)
+	}
+	if before.(*http.Transport).MaxConnsPerHost != 0 {
+		t.Error("transport in use was modified")
+	}
+}
+
+func TestRequestMaxConnsPerHost(t *testing.T) {
+	transport := Get("http://example.com").SetMaxConnsPerHost(2).client().Transport.(*http.Transport)
+	if transport.MaxConnsPerHost != 2 {
+		t.Errorf("MaxConnsPerHost = %d, want 2", transport.MaxConnsPerHost)
+	}
+	if Get("http://example.com").SetMaxConnsPerHost(2).client().Transport != transport {
+		t.Error("requests with the same limit use different transports")
+	}
+}
+
+func TestMaxConnsPerHostLimitsConcurrency(t *testing.T) {
+	var inFlight, peak int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(50 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			response, err := Get(server.URL).SetMaxConnsPerHost(2).Do()
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			response.Body.Close()
+		}()
+	}
+	wg.Wait()
+	if peak > 2 {
+		t.Errorf("server saw up to %d concurrent requests, want at most 2", peak)
+	}
+}
+
+func TestSmallBuffersCarryLargeBodies(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		w.Write(body)
+	}))
+	defer server.Close()
+
+	payload := bytes.Repeat([]byte("0123456789abcdef"), 1<<14)
+	response, err := Post(server.URL).
+		SetPayload(payload).
+		SetReadBufferSize(512).
+		SetWriteBufferSize(512).
+		Do()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+	echoed, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(echoed, payload) {
+		t.Errorf("echoed %d bytes, want the %d sent", len(echoed), len(payload))
+	}
+}
+
+func TestHeadersTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			time.Sleep(200 * time.Millisecond)
+		}
+		w.WriteHeader(http.StatusOK)
+		for i := 0; i < 4; i++ {
+			w.Write([]byte("chunk\n"))
+			w.(http.Flusher).Flush()
+			time.Sleep(50 * time.Millisecond)
+		}
+	}))
+	defer server.Close()
+
+	_, err := Get(server.URL + "/slow").SetHeadersTimeout(50 * time.Millisecond).Do()
+	var timeout *TimeoutError
+	if !errors.As(err, &timeout) {
+		t.Errorf("err = %v, want a *TimeoutError for slow response headers", err)
+	}
+
+	response, err := Get(server.URL).SetHeadersTimeout(100 * time.Millisecond).Do()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil || len(body) != 24 {
+		t.Errorf("read %q, %v; want the whole streamed body", body, err)
+	}
+}
+
+func TestHeadersTimeoutReplacesAttemptTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		time.Sleep(100 * time.Millisecond)
+		w.Write([]byte("late"))
+	}))
+	defer server.Close()
+
+	response, err := Get(server.URL).SetTimeout(20 * time.Millisecond).SetHeadersTimeout(time.Second).Do()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+	if body, err := ioutil.ReadAll(response.Body); err != nil || string(body) != "late" {
+		t.Errorf("read %q, %v; want the body past the ignored attempt timeout", body, err)
+	}
+}
+
+func TestHeadersTimeoutCoversConnectionSetup(t *testing.T) {
+	// The listener accepts connections but never answers the TLS handshake.
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	start := time.Now()
+	_, err = Get("https://" + listener.Addr().String()).SetHeadersTimeout(50 * time.Millisecond).Do()
+	var timeout *TimeoutError
+	if !errors.As(err, &timeout) {
+		t.Errorf("err = %v, want a *TimeoutError for a stalled handshake", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("gave up after %v, want the headers timeout to bound the handshake", elapsed)
+	}
+}
@@ -0,0 +1,33 @@
+package webhook
+
+import (
+	"context"
+	"sync"
+)
+
+// MemoryStore keeps delivery attempts in memory, mostly for tests and
+// single-process services.
+type MemoryStore struct {
+	mu       sync.Mutex
+	attempts map[string][]Attempt
+}
+
+// NewMemoryStore returns an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{attempts: make(map[string][]Attempt)}
+}
+
+// RecordAttempt implements Store.
+func (s *MemoryStore) RecordAttempt(_ context.Context, attempt Attempt) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attempts[attempt.DeliveryID] = append(s.attempts[attempt.DeliveryID], attempt)
+	return nil
+}
+
+// Attempts returns the attempts recorded for a delivery, oldest first.
+func (s *MemoryStore) Attempts(deliveryID string) []Attempt {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Attempt(nil), s.attempts[deliveryID]...)
+}
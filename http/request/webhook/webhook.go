@@ -0,0 +1,257 @@
+// Package webhook delivers signed payloads to subscriber URLs with the
+// request package, retrying on a schedule until the subscriber acknowledges
+// a delivery with a 2xx response.
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/metamemelord/go-utilities/http/request"
+	"github.com/metamemelord/go-utilities/retry"
+)
+
+// Headers set on every delivery attempt. The signature is the hex-encoded
+// HMAC-SHA256, keyed with the dispatcher secret, of the timestamp, a '.',
+// then the body.
+const (
+	IDHeader        = "Webhook-ID"
+	EventHeader     = "Webhook-Event"
+	TimestampHeader = "Webhook-Timestamp"
+	SignatureHeader = "Webhook-Signature"
+)
+
+// DefaultSchedule is the wait before each retry: a delivery is attempted up
+// to eight times over a little more than a day.
+var DefaultSchedule = []time.Duration{
+	time.Minute,
+	5 * time.Minute,
+	30 * time.Minute,
+	time.Hour,
+	2 * time.Hour,
+	6 * time.Hour,
+	16 * time.Hour,
+}
+
+// ErrGone is returned when a subscriber answers 410 Gone, meaning it no
+// longer wants deliveries. It is not retried.
+var ErrGone = errors.New("Subscriber is gone")
+
+// Delivery is a payload to deliver to one subscriber.
+type Delivery struct {
+	// ID identifies the delivery across attempts so subscribers can drop
+	// duplicates. Deliver generates one if it is empty.
+	ID      string
+	URL     string
+	Event   string
+	Payload []byte
+}
+
+// Attempt records one delivery attempt.
+type Attempt struct {
+	DeliveryID string
+	Number     int
+	At         time.Time
+	Duration   time.Duration
+	// StatusCode is zero if no response was received.
+	StatusCode int
+	// Error is empty if the attempt was acknowledged.
+	Error string
+}
+
+// Store records delivery attempts. Implementations must be safe for
+// concurrent use.
+type Store interface {
+	RecordAttempt(ctx context.Context, attempt Attempt) error
+}
+
+// Option configures New.
+type Option func(*Dispatcher)
+
+// WithSchedule sets the wait before each retry. A delivery is attempted once
+// more than the schedule has entries. The default is DefaultSchedule.
+func WithSchedule(schedule ...time.Duration) Option {
+	return func(d *Dispatcher) {
+		d.schedule = schedule
+	}
+}
+
+// WithStore records every attempt in store.
+func WithStore(store Store) Option {
+	return func(d *Dispatcher) {
+		d.store = store
+	}
+}
+
+// WithDeadLetter calls fn with a delivery that was never acknowledged and
+// the last error, after its final attempt.
+func WithDeadLetter(fn func(ctx context.Context, delivery Delivery, err error)) Option {
+	return func(d *Dispatcher) {
+		d.deadLetter = fn
+	}
+}
+
+// WithTimeout bounds each attempt. The default is 10 seconds.
+func WithTimeout(timeout time.Duration) Option {
+	return func(d *Dispatcher) {
+		d.timeout = timeout
+	}
+}
+
+// WithSSRFProtection refuses to deliver to private addresses and to hosts
+// outside options.AllowedHosts, for subscriber URLs supplied by users.
+func WithSSRFProtection(options request.SSRFOptions) Option {
+	return func(d *Dispatcher) {
+		d.ssrf = &options
+	}
+}
+
+// WithLogger routes the logging of delivery requests and of store failures
+// through logger.
+func WithLogger(logger request.Logger) Option {
+	return func(d *Dispatcher) {
+		d.logger = logger
+	}
+}
+
+// Dispatcher delivers webhooks signed with one secret. It is safe for
+// concurrent use.
+type Dispatcher struct {
+	secret     []byte
+	schedule   []time.Duration
+	timeout    time.Duration
+	store      Store
+	deadLetter func(ctx context.Context, delivery Delivery, err error)
+	ssrf       *request.SSRFOptions
+	logger     request.Logger
+}
+
+// New returns a Dispatcher signing deliveries with secret.
+func New(secret []byte, options ...Option) *Dispatcher {
+	d := &Dispatcher{
+		secret:   secret,
+		schedule: DefaultSchedule,
+		timeout:  10 * time.Second,
+		logger:   request.StdLogger{},
+	}
+	for _, option := range options {
+		option(d)
+	}
+	return d
+}
+
+// Deliver sends delivery until it is acknowledged, the schedule runs out or
+// ctx is done, blocking in between attempts; use a goroutine or a worker
+// pool for long schedules. It returns nil once acknowledged, and otherwise
+// the last error after passing it to the dead-letter callback. A canceled
+// ctx is not dead-lettered.
+func (d *Dispatcher) Deliver(ctx context.Context, delivery Delivery) error {
+	if delivery.ID == "" {
+		delivery.ID = newID()
+	}
+
+	number := 0
+	err := retry.Do(ctx, func() error {
+		number++
+		return d.attempt(ctx, delivery, number)
+	},
+		retry.Attempts(len(d.schedule)+1),
+		retry.WithBackoff(func(attempt int) time.Duration {
+			return d.schedule[attempt-1]
+		}),
+	)
+	if err == nil || ctx.Err() != nil {
+		return err
+	}
+	if d.deadLetter != nil {
+		d.deadLetter(ctx, delivery, err)
+	}
+	return err
+}
+
+// attempt sends delivery once. The request's own retries are disabled so that
+// the schedule is the only retry mechanism and every attempt is signed afresh
+// and recorded.
+func (d *Dispatcher) attempt(ctx context.Context, delivery Delivery, number int) error {
+	builder := request.Post(delivery.URL).
+		SetContext(ctx).
+		SetRetries(0).
+		SetTimeout(d.timeout).
+		SetLogger(d.logger).
+		SetPayload(delivery.Payload).
+		SetHeader("Content-Type", "application/json").
+		SetHeader(IDHeader, delivery.ID).
+		SetSigner(request.HMACSigner(d.secret, SignatureHeader, TimestampHeader))
+	if delivery.Event != "" {
+		builder.SetHeader(EventHeader, delivery.Event)
+	}
+	if d.ssrf != nil {
+		builder.SetSSRFProtection(*d.ssrf)
+	}
+
+	record := Attempt{DeliveryID: delivery.ID, Number: number, At: time.Now()}
+	response, err := builder.DoWrapped()
+	record.Duration = time.Since(record.At)
+	if err == nil {
+		record.StatusCode = response.StatusCode()
+		if !response.IsSuccess() {
+			err = fmt.Errorf("Subscriber answered %s", response.Raw().Status)
+		}
+		if response.StatusCode() == http.StatusGone {
+			err = retry.Unrecoverable(ErrGone)
+		}
+	}
+	if err != nil {
+		record.Error = err.Error()
+	}
+
+	if d.store != nil {
+		if storeErr := d.store.RecordAttempt(ctx, record); storeErr != nil {
+			d.logger.Error("Could not record webhook attempt", request.F("delivery", delivery.ID), request.F("error", storeErr))
+		}
+	}
+	if errors.Is(err, request.ErrBlockedDestination) {
+		return retry.Unrecoverable(err)
+	}
+	return err
+}
+
+// Verify checks the signature headers of a received webhook against body,
+// rejecting timestamps more than tolerance away from now to prevent replays.
+// Subscribers written in Go can use it to authenticate deliveries.
+func Verify(secret []byte, header http.Header, body []byte, tolerance time.Duration) error {
+	timestamp := header.Get(TimestampHeader)
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("Invalid %s header", TimestampHeader)
+	}
+	if age := time.Since(time.Unix(seconds, 0)); age > tolerance || age < -tolerance {
+		return fmt.Errorf("Webhook timestamp is outside the %s tolerance", tolerance)
+	}
+
+	signature, err := hex.DecodeString(header.Get(SignatureHeader))
+	if err != nil {
+		return fmt.Errorf("Invalid %s header", SignatureHeader)
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return errors.New("Webhook signature does not match")
+	}
+	return nil
+}
+
+func newID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
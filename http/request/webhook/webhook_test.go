@@ -0,0 +1,254 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/metamemelord/go-utilities/http/request"
+)
+
+var secret = []byte("shh")
+
+// quiet keeps the request logging of failed attempts out of the test output.
+var quiet = WithLogger(request.StdLogger{Logger: log.New(ioutil.Discard, "", 0)})
+
+// subscriber answers deliveries with the next of its statuses and keeps the
+// requests it received.
+type subscriber struct {
+	mu       sync.Mutex
+	statuses []int
+	headers  []http.Header
+	bodies   [][]byte
+}
+
+func (s *subscriber) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := ioutil.ReadAll(r.Body)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.headers = append(s.headers, r.Header.Clone())
+	s.bodies = append(s.bodies, body)
+	status := http.StatusOK
+	if len(s.statuses) > 0 {
+		status, s.statuses = s.statuses[0], s.statuses[1:]
+	}
+	w.WriteHeader(status)
+}
+
+func (s *subscriber) received() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.headers)
+}
+
+func TestSignatureHeaders(t *testing.T) {
+	sub := &subscriber{}
+	server := httptest.NewServer(sub)
+	defer server.Close()
+
+	d := New(secret, quiet)
+	payload := []byte(`{"order":42}`)
+	err := d.Deliver(context.Background(), Delivery{ID: "dlv-1", URL: server.URL, Event: "order.created", Payload: payload})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	header, body := sub.headers[0], sub.bodies[0]
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{IDHeader, "dlv-1"},
+		{EventHeader, "order.created"},
+		{"Content-Type", "application/json"},
+	}
+	for _, test := range tests {
+		if got := header.Get(test.header); got != test.want {
+			t.Errorf("%s = %q, want %q", test.header, got, test.want)
+		}
+	}
+	if _, err := strconv.ParseInt(header.Get(TimestampHeader), 10, 64); err != nil {
+		t.Errorf("%s = %q, want Unix seconds", TimestampHeader, header.Get(TimestampHeader))
+	}
+	if err := Verify(secret, header, body, time.Minute); err != nil {
+		t.Errorf("Verify: %v", err)
+	}
+	if err := Verify([]byte("other"), header, body, time.Minute); err == nil {
+		t.Error("Verify accepted a signature made with another secret")
+	}
+	if err := Verify(secret, header, []byte(`{"order":43}`), time.Minute); err == nil {
+		t.Error("Verify accepted a tampered body")
+	}
+}
+
+func TestVerifyRejectsBadHeaders(t *testing.T) {
+	stale := strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10)
+	tests := []struct {
+		name      string
+		timestamp string
+		signature string
+	}{
+		{"missing timestamp", "", "00"},
+		{"stale timestamp", stale, "00"},
+		{"signature not hex", strconv.FormatInt(time.Now().Unix(), 10), "zz"},
+	}
+	for _, test := range tests {
+		header := http.Header{}
+		header.Set(TimestampHeader, test.timestamp)
+		header.Set(SignatureHeader, test.signature)
+		if err := Verify(secret, header, nil, time.Minute); err == nil {
+			t.Errorf("%s: Verify succeeded", test.name)
+		}
+	}
+}
+
+func TestScheduleRunsToExhaustion(t *testing.T) {
+	sub := &subscriber{statuses: []int{500, 500, 500, 500}}
+	server := httptest.NewServer(sub)
+	defer server.Close()
+
+	store := NewMemoryStore()
+	var deadLettered []Delivery
+	var deadErr error
+	d := New(secret, quiet,
+		WithSchedule(time.Millisecond, time.Millisecond, time.Millisecond),
+		WithStore(store),
+		WithDeadLetter(func(_ context.Context, delivery Delivery, err error) {
+			deadLettered = append(deadLettered, delivery)
+			deadErr = err
+		}),
+	)
+	err := d.Deliver(context.Background(), Delivery{ID: "dlv-2", URL: server.URL, Payload: []byte(`{}`)})
+	if err == nil {
+		t.Fatal("Deliver succeeded against a failing subscriber")
+	}
+	if n := sub.received(); n != 4 {
+		t.Errorf("subscriber received %d attempts, want 4", n)
+	}
+	if len(deadLettered) != 1 || deadLettered[0].ID != "dlv-2" || deadErr != err {
+		t.Errorf("dead letters = %v with %v, want dlv-2 with %v", deadLettered, deadErr, err)
+	}
+
+	attempts := store.Attempts("dlv-2")
+	if len(attempts) != 4 {
+		t.Fatalf("store has %d attempts, want 4", len(attempts))
+	}
+	for i, attempt := range attempts {
+		if attempt.Number != i+1 || attempt.StatusCode != 500 || attempt.Error == "" || attempt.At.IsZero() {
+			t.Errorf("attempt %d = %+v, want a failed 500", i, attempt)
+		}
+	}
+}
+
+func TestRetriedUntilAcknowledged(t *testing.T) {
+	sub := &subscriber{statuses: []int{503, 502}}
+	server := httptest.NewServer(sub)
+	defer server.Close()
+
+	store := NewMemoryStore()
+	deadLettered := false
+	d := New(secret, quiet,
+		WithSchedule(time.Millisecond, time.Millisecond, time.Millisecond),
+		WithStore(store),
+		WithDeadLetter(func(context.Context, Delivery, error) { deadLettered = true }),
+	)
+	if err := d.Deliver(context.Background(), Delivery{ID: "dlv-3", URL: server.URL}); err != nil {
+		t.Fatal(err)
+	}
+	if deadLettered {
+		t.Error("an acknowledged delivery was dead-lettered")
+	}
+	attempts := store.Attempts("dlv-3")
+	if len(attempts) != 3 || attempts[2].StatusCode != 200 || attempts[2].Error != "" {
+		t.Errorf("attempts = %+v, want two failures then an acknowledgement", attempts)
+	}
+	ids := map[string]bool{}
+	for _, header := range sub.headers {
+		ids[header.Get(IDHeader)] = true
+	}
+	if len(ids) != 1 || !ids["dlv-3"] {
+		t.Errorf("delivery IDs = %v, want the same ID on every attempt", ids)
+	}
+}
+
+func TestGoneIsNotRetried(t *testing.T) {
+	sub := &subscriber{statuses: []int{http.StatusGone}}
+	server := httptest.NewServer(sub)
+	defer server.Close()
+
+	d := New(secret, quiet, WithSchedule(time.Millisecond, time.Millisecond))
+	if err := d.Deliver(context.Background(), Delivery{URL: server.URL}); !errors.Is(err, ErrGone) {
+		t.Errorf("err = %v, want ErrGone", err)
+	}
+	if n := sub.received(); n != 1 {
+		t.Errorf("subscriber received %d attempts, want 1", n)
+	}
+}
+
+func TestRequestRetriesAreDisabled(t *testing.T) {
+	request.SetDefaults(request.Defaults{Timeout: time.Second, Retries: 3})
+	defer request.SetDefaults(request.Defaults{Timeout: 30 * time.Second})
+
+	var mu sync.Mutex
+	hits := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		hits++
+		mu.Unlock()
+		conn, _, _ := w.(http.Hijacker).Hijack()
+		conn.Close()
+	}))
+	defer server.Close()
+
+	store := NewMemoryStore()
+	d := New(secret, quiet, WithSchedule(time.Millisecond), WithStore(store))
+	if err := d.Deliver(context.Background(), Delivery{ID: "dlv-4", URL: server.URL}); err == nil {
+		t.Fatal("Deliver succeeded against a server that drops connections")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if attempts := store.Attempts("dlv-4"); hits != 2 || len(attempts) != 2 {
+		t.Errorf("server was hit %d times with %d recorded attempts, want 2 of each", hits, len(attempts))
+	}
+}
+
+func TestBlockedDestinationIsNotRetried(t *testing.T) {
+	sub := &subscriber{}
+	server := httptest.NewServer(sub)
+	defer server.Close()
+
+	d := New(secret, quiet, WithSchedule(time.Millisecond, time.Millisecond), WithSSRFProtection(request.SSRFOptions{}))
+	if err := d.Deliver(context.Background(), Delivery{URL: server.URL}); !errors.Is(err, request.ErrBlockedDestination) {
+		t.Errorf("err = %v, want ErrBlockedDestination", err)
+	}
+	if n := sub.received(); n != 0 {
+		t.Errorf("subscriber received %d attempts, want none", n)
+	}
+}
+
+func TestCanceledDeliveryIsNotDeadLettered(t *testing.T) {
+	sub := &subscriber{statuses: []int{500}}
+	server := httptest.NewServer(sub)
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	deadLettered := false
+	d := New(secret, quiet,
+		WithSchedule(time.Hour),
+		WithDeadLetter(func(context.Context, Delivery, error) { deadLettered = true }),
+	)
+	time.AfterFunc(20*time.Millisecond, cancel)
+	if err := d.Deliver(ctx, Delivery{URL: server.URL}); !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+	if deadLettered {
+		t.Error("a canceled delivery was dead-lettered")
+	}
+}
@@ -0,0 +1,38 @@
+package request
+
+import (
+	"encoding/xml"
+	"fmt"
+
+	"go.uber.org/multierr"
+)
+
+// SetXMLPayload sets the XML encoding of v as the payload along with a
+// Content-Type of application/xml. Encoding errors are reported by Do.
+func (h *httpRequest) SetXMLPayload(v interface{}) *httpRequest {
+	payload, err := xml.Marshal(v)
+	if err != nil {
+		h.err = multierr.Append(h.err, fmt.Errorf("Could not encode XML payload: %v", err))
+		return h
+	}
+	return h.SetHeader("Content-Type", "application/xml").SetPayload(payload)
+}
+
+// DoAndDecodeXML performs the request and decodes the XML response body into
+// target. Non-2xx responses are returned as a *StatusError.
+func (h *httpRequest) DoAndDecodeXML(target interface{}) error {
+	if h.request.Header.Get("Accept") == "" {
+		h.SetHeader("Accept", "application/xml")
+	}
+
+	response, err := h.Do()
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if !isSuccess(response.StatusCode) {
+		return newStatusError(response)
+	}
+	return xml.NewDecoder(response.Body).Decode(target)
+}
@@ -0,0 +1,36 @@
+// Package zaplogger adapts a zap logger to the request package's Logger.
+package zaplogger
+
+import (
+	"github.com/metamemelord/go-utilities/http/request"
+	"go.uber.org/zap"
+)
+
+type logger struct {
+	logger *zap.Logger
+}
+
+// New returns a request.Logger that writes to l.
+func New(l *zap.Logger) request.Logger {
+	return logger{logger: l.WithOptions(zap.AddCallerSkip(1))}
+}
+
+func (l logger) Debug(msg string, fields ...request.Field) {
+	l.logger.Debug(msg, zapFields(fields)...)
+}
+
+func (l logger) Info(msg string, fields ...request.Field) {
+	l.logger.Info(msg, zapFields(fields)...)
+}
+
+func (l logger) Error(msg string, fields ...request.Field) {
+	l.logger.Error(msg, zapFields(fields)...)
+}
+
+func zapFields(fields []request.Field) []zap.Field {
+	converted := make([]zap.Field, 0, len(fields))
+	for _, field := range fields {
+		converted = append(converted, zap.Any(field.Key, field.Value))
+	}
+	return converted
+}
@@ -0,0 +1,182 @@
+// Package lifecycle starts the components of a service in order and stops
+// them in reverse order when the process is asked to terminate.
+//
+// A typical main registers a hook per component and calls Run:
+//
+//	m := lifecycle.New(lifecycle.WithShutdownTimeout(15 * time.Second))
+//	m.Append(lifecycle.Hook{Name: "db", OnStart: db.Connect, OnStop: db.Close})
+//	m.Append(lifecycle.Hook{Name: "http client", OnStop: request.Shutdown})
+//	if err := m.Run(context.Background()); err != nil {
+//		log.Fatal(err)
+//	}
+package lifecycle
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"go.uber.org/multierr"
+)
+
+// Hook is a component's start and stop functions. Either may be nil. OnStop
+// is only called if OnStart succeeded.
+type Hook struct {
+	Name    string
+	OnStart func(ctx context.Context) error
+	OnStop  func(ctx context.Context) error
+}
+
+// HookError reports a hook that failed during phase "start" or "stop".
+type HookError struct {
+	Name  string
+	Phase string
+	Err   error
+}
+
+func (e *HookError) Error() string {
+	return fmt.Sprintf("Failed to %s %s: %v", e.Phase, e.Name, e.Err)
+}
+
+func (e *HookError) Unwrap() error {
+	return e.Err
+}
+
+// Option configures New.
+type Option func(*Manager)
+
+// WithShutdownTimeout bounds how long Run waits for the stop hooks. The
+// default is 30 seconds.
+func WithShutdownTimeout(d time.Duration) Option {
+	return func(m *Manager) {
+		m.timeout = d
+	}
+}
+
+// WithSignals sets the signals that make Run shut down. The default is
+// SIGINT and SIGTERM.
+func WithSignals(signals ...os.Signal) Option {
+	return func(m *Manager) {
+		m.signals = signals
+	}
+}
+
+// Manager runs registered hooks. It is safe for concurrent use.
+type Manager struct {
+	mu      sync.Mutex
+	hooks   []Hook
+	started int
+	timeout time.Duration
+	signals []os.Signal
+}
+
+// New returns a Manager with no hooks.
+func New(options ...Option) *Manager {
+	m := &Manager{
+		timeout: 30 * time.Second,
+		signals: []os.Signal{os.Interrupt, syscall.SIGTERM},
+	}
+	for _, option := range options {
+		option(m)
+	}
+	return m
+}
+
+// Append registers hook. Hooks start in the order they were appended and
+// stop in reverse.
+func (m *Manager) Append(hook Hook) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.hooks = append(m.hooks, hook)
+}
+
+// OnStart registers a start hook with no matching stop hook.
+func (m *Manager) OnStart(name string, fn func(ctx context.Context) error) {
+	m.Append(Hook{Name: name, OnStart: fn})
+}
+
+// OnStop registers a stop hook with no matching start hook.
+func (m *Manager) OnStop(name string, fn func(ctx context.Context) error) {
+	m.Append(Hook{Name: name, OnStop: fn})
+}
+
+// Start runs the start hooks that have not run yet, in order. If one fails,
+// the hooks already started are stopped in reverse and the combined errors
+// are returned.
+func (m *Manager) Start(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for m.started < len(m.hooks) {
+		hook := m.hooks[m.started]
+		if hook.OnStart != nil {
+			if err := run(ctx, hook.OnStart); err != nil {
+				err = &HookError{Name: hook.Name, Phase: "start", Err: err}
+				return multierr.Append(err, m.stop(ctx))
+			}
+		}
+		m.started++
+	}
+	return nil
+}
+
+// Stop runs the stop hooks of every started hook in reverse order, even if
+// some fail, and returns their combined errors. If ctx is done first, the
+// hooks that have not run are reported as failed with ctx's error.
+func (m *Manager) Stop(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.stop(ctx)
+}
+
+func (m *Manager) stop(ctx context.Context) error {
+	var errs error
+	for ; m.started > 0; m.started-- {
+		hook := m.hooks[m.started-1]
+		if hook.OnStop == nil {
+			continue
+		}
+		if err := run(ctx, hook.OnStop); err != nil {
+			errs = multierr.Append(errs, &HookError{Name: hook.Name, Phase: "stop", Err: err})
+		}
+	}
+	return errs
+}
+
+// Run starts every hook, blocks until one of the configured signals is
+// received or ctx is done, then stops the hooks within the shutdown timeout.
+// It returns the errors of both phases.
+func (m *Manager) Run(ctx context.Context) error {
+	if err := m.Start(ctx); err != nil {
+		return err
+	}
+
+	signals, stop := signal.NotifyContext(ctx, m.signals...)
+	<-signals.Done()
+	stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeout)
+	defer cancel()
+	return m.Stop(ctx)
+}
+
+// run calls fn, returning ctx's error instead if ctx is done before fn
+// returns, so a hook that ignores its context cannot stall the others.
+func run(ctx context.Context, fn func(ctx context.Context) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- fn(ctx)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
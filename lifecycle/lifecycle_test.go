@@ -0,0 +1,167 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/signal"
+	"reflect"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// recorder records the order in which hooks run.
+type recorder struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+func (r *recorder) hook(name string, startErr error) Hook {
+	return Hook{
+		Name: name,
+		OnStart: func(context.Context) error {
+			r.record("start " + name)
+			return startErr
+		},
+		OnStop: func(context.Context) error {
+			r.record("stop " + name)
+			return nil
+		},
+	}
+}
+
+func (r *recorder) record(call string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, call)
+}
+
+func (r *recorder) get() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.calls...)
+}
+
+func TestStopsInReverseOrder(t *testing.T) {
+	var r recorder
+	m := New()
+	m.Append(r.hook("db", nil))
+	m.Append(r.hook("cache", nil))
+	m.OnStop("flush", func(context.Context) error {
+		r.record("stop flush")
+		return nil
+	})
+
+	if err := m.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"start db", "start cache", "stop flush", "stop cache", "stop db"}
+	if got := r.get(); !reflect.DeepEqual(got, want) {
+		t.Errorf("calls = %v, want %v", got, want)
+	}
+}
+
+func TestStartFailureRollsBack(t *testing.T) {
+	var r recorder
+	failure := errors.New("connection refused")
+	m := New()
+	m.Append(r.hook("db", nil))
+	m.Append(r.hook("cache", nil))
+	m.Append(r.hook("queue", failure))
+	m.Append(r.hook("server", nil))
+
+	err := m.Start(context.Background())
+	var hookError *HookError
+	if !errors.As(err, &hookError) || hookError.Name != "queue" || hookError.Phase != "start" || !errors.Is(err, failure) {
+		t.Fatalf("err = %v, want a start HookError for queue", err)
+	}
+	want := []string{"start db", "start cache", "start queue", "stop cache", "stop db"}
+	if got := r.get(); !reflect.DeepEqual(got, want) {
+		t.Errorf("calls = %v, want %v", got, want)
+	}
+}
+
+func TestStopContinuesAfterFailures(t *testing.T) {
+	var r recorder
+	m := New()
+	m.Append(r.hook("db", nil))
+	m.OnStop("broken", func(context.Context) error { return errors.New("broken") })
+	m.Start(context.Background())
+
+	err := m.Stop(context.Background())
+	var hookError *HookError
+	if !errors.As(err, &hookError) || hookError.Name != "broken" || hookError.Phase != "stop" {
+		t.Errorf("err = %v, want a stop HookError for broken", err)
+	}
+	if got := r.get(); len(got) != 2 || got[1] != "stop db" {
+		t.Errorf("calls = %v, want db stopped after the failure", got)
+	}
+}
+
+func TestShutdownTimeout(t *testing.T) {
+	var r recorder
+	m := New(WithShutdownTimeout(20 * time.Millisecond))
+	m.Append(r.hook("db", nil))
+	release := make(chan struct{})
+	defer close(release)
+	m.OnStop("stuck", func(context.Context) error {
+		<-release
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+	start := time.Now()
+	err := m.Run(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want the shutdown timeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Run took %v to give up on a stuck hook", elapsed)
+	}
+	if got := r.get(); len(got) != 1 {
+		t.Errorf("calls = %v, want hooks after the timeout to be skipped", got)
+	}
+}
+
+func TestRunStopsOnSignal(t *testing.T) {
+	var r recorder
+	m := New(WithSignals(syscall.SIGUSR1))
+	m.Append(r.hook("server", nil))
+
+	// Keep the signal from killing the test if it arrives before Run listens.
+	ignored := make(chan os.Signal, 1)
+	signal.Notify(ignored, syscall.SIGUSR1)
+	defer signal.Stop(ignored)
+
+	done := make(chan error, 1)
+	go func() { done <- m.Run(context.Background()) }()
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+	timeout := time.After(5 * time.Second)
+	for stopped := false; !stopped; {
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Fatal(err)
+			}
+			stopped = true
+		case <-ticker.C:
+			syscall.Kill(syscall.Getpid(), syscall.SIGUSR1)
+		case <-timeout:
+			t.Fatal("Run did not return after the signal")
+		}
+	}
+	want := []string{"start server", "stop server"}
+	if got := r.get(); !reflect.DeepEqual(got, want) {
+		t.Errorf("calls = %v, want %v", got, want)
+	}
+}
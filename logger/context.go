@@ -0,0 +1,72 @@
+package logger
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+)
+
+type loggerContextKey struct{}
+
+type requestIDContextKey struct{}
+
+// NewContext returns a copy of ctx carrying l.
+func NewContext(ctx context.Context, l *Logger) context.Context {
+	return context.WithValue(ctx, loggerContextKey{}, l)
+}
+
+// FromContext returns the logger carried by ctx, or Default if there is
+// none, with the request ID of ctx attached.
+func FromContext(ctx context.Context) *Logger {
+	l, ok := ctx.Value(loggerContextKey{}).(*Logger)
+	if !ok {
+		l = defaultLogger
+	}
+	return l.Ctx(ctx)
+}
+
+// ContextWithRequestID returns a copy of ctx carrying a request ID, which
+// loggers obtained with Ctx or FromContext attach to every entry.
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDContextKey{}, id)
+}
+
+// RequestID returns the request ID carried by ctx, or "".
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDContextKey{}).(string)
+	return id
+}
+
+// Ctx returns a logger that adds the request ID of ctx, if any, to every
+// entry under the "request_id" key.
+func (l *Logger) Ctx(ctx context.Context) *Logger {
+	id := RequestID(ctx)
+	if id == "" {
+		return l
+	}
+	return l.With("request_id", id)
+}
+
+// RequestIDHeader is the header used to receive and propagate request IDs.
+const RequestIDHeader = "X-Request-ID"
+
+// RequestIDMiddleware makes next see the request ID from the X-Request-ID
+// header, or a new random one, in its request context, and echoes it in the
+// response header.
+func RequestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if id == "" {
+			id = newRequestID()
+		}
+		w.Header().Set(RequestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(ContextWithRequestID(r.Context(), id)))
+	})
+}
+
+func newRequestID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
@@ -0,0 +1,81 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestContextInheritsFields(t *testing.T) {
+	var buf bytes.Buffer
+	log := New(&buf).With("service", "api")
+	ctx := ContextWithRequestID(NewContext(context.Background(), log), "req-1")
+
+	FromContext(ctx).With("step", "load").Info("Loaded")
+	log.Ctx(ctx).Info("Direct")
+	log.Ctx(context.Background()).Info("Without ID")
+
+	entries := decode(t, &buf)
+	if len(entries) != 3 {
+		t.Fatalf("wrote %d entries, want 3", len(entries))
+	}
+	tests := []struct {
+		service, requestID, step interface{}
+	}{
+		{"api", "req-1", "load"},
+		{"api", "req-1", nil},
+		{"api", nil, nil},
+	}
+	for i, test := range tests {
+		if entries[i]["service"] != test.service || entries[i]["request_id"] != test.requestID || entries[i]["step"] != test.step {
+			t.Errorf("entry %d = %v, want service %v, request_id %v, step %v",
+				i, entries[i], test.service, test.requestID, test.step)
+		}
+	}
+}
+
+func TestFromContextDefault(t *testing.T) {
+	if l := FromContext(context.Background()); l != Default() {
+		t.Error("FromContext without a logger did not return Default")
+	}
+	if l := FromContext(ContextWithRequestID(context.Background(), "id")); l.core != Default().core || len(l.fields) != 1 {
+		t.Error("FromContext did not attach the request ID to Default")
+	}
+}
+
+func TestRequestIDMiddleware(t *testing.T) {
+	var seen string
+	handler := RequestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = RequestID(r.Context())
+	}))
+
+	tests := []struct {
+		name   string
+		header string
+	}{
+		{"propagated", "upstream-id"},
+		{"generated", ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if test.header != "" {
+				r.Header.Set(RequestIDHeader, test.header)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if test.header != "" && seen != test.header {
+				t.Errorf("handler saw %q, want %q", seen, test.header)
+			}
+			if test.header == "" && len(seen) != 32 {
+				t.Errorf("generated ID %q, want 32 hex characters", seen)
+			}
+			if echoed := w.Header().Get(RequestIDHeader); echoed != seen {
+				t.Errorf("response header = %q, want %q", echoed, seen)
+			}
+		})
+	}
+}
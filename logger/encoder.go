@@ -0,0 +1,88 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Entry is a single log message.
+type Entry struct {
+	Time    time.Time
+	Level   Level
+	Message string
+	Fields  []Field
+}
+
+// Encoder formats an entry into buf, without a trailing newline.
+type Encoder interface {
+	Encode(buf *bytes.Buffer, entry Entry)
+}
+
+// JSONEncoder writes one JSON object per entry with "time", "level" and
+// "msg" keys followed by the entry's fields.
+type JSONEncoder struct{}
+
+// Encode implements Encoder.
+func (JSONEncoder) Encode(buf *bytes.Buffer, entry Entry) {
+	buf.WriteString(`{"time":`)
+	writeJSON(buf, entry.Time.Format(time.RFC3339Nano))
+	buf.WriteString(`,"level":`)
+	writeJSON(buf, entry.Level.String())
+	buf.WriteString(`,"msg":`)
+	writeJSON(buf, entry.Message)
+	for _, field := range entry.Fields {
+		buf.WriteByte(',')
+		writeJSON(buf, field.Key)
+		buf.WriteByte(':')
+		writeJSON(buf, jsonValue(field.Value))
+	}
+	buf.WriteByte('}')
+}
+
+// jsonValue converts values that would not marshal usefully, such as errors,
+// which marshal to an empty object.
+func jsonValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case error:
+		return v.Error()
+	case fmt.Stringer:
+		return v.String()
+	}
+	return value
+}
+
+func writeJSON(buf *bytes.Buffer, value interface{}) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		data, _ = json.Marshal(fmt.Sprint(value))
+	}
+	buf.Write(data)
+}
+
+// ConsoleEncoder writes entries as
+// "2006-01-02T15:04:05.000Z07:00 INFO msg key=value", quoting values that
+// contain spaces or quotes.
+type ConsoleEncoder struct{}
+
+// Encode implements Encoder.
+func (ConsoleEncoder) Encode(buf *bytes.Buffer, entry Entry) {
+	buf.WriteString(entry.Time.Format("2006-01-02T15:04:05.000Z07:00"))
+	buf.WriteByte(' ')
+	fmt.Fprintf(buf, "%-5s", strings.ToUpper(entry.Level.String()))
+	buf.WriteByte(' ')
+	buf.WriteString(entry.Message)
+	for _, field := range entry.Fields {
+		buf.WriteByte(' ')
+		buf.WriteString(field.Key)
+		buf.WriteByte('=')
+		text := fmt.Sprint(jsonValue(field.Value))
+		if text == "" || strings.ContainsAny(text, " \t\n\"=") {
+			text = strconv.Quote(text)
+		}
+		buf.WriteString(text)
+	}
+}
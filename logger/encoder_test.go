@@ -0,0 +1,104 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+var encodeTime = time.Date(2024, 3, 1, 12, 30, 45, 123456789, time.UTC)
+
+func TestJSONEncoder(t *testing.T) {
+	tests := []struct {
+		name   string
+		entry  Entry
+		expect string
+	}{
+		{
+			name:   "no fields",
+			entry:  Entry{Time: encodeTime, Level: InfoLevel, Message: "started"},
+			expect: `{"time":"2024-03-01T12:30:45.123456789Z","level":"info","msg":"started"}`,
+		},
+		{
+			name: "fields keep their order",
+			entry: Entry{Time: encodeTime, Level: ErrorLevel, Message: `say "hi"`, Fields: []Field{
+				{"z", 1}, {"a", true}, {"list", []int{1, 2}},
+			}},
+			expect: `{"time":"2024-03-01T12:30:45.123456789Z","level":"error","msg":"say \"hi\"","z":1,"a":true,"list":[1,2]}`,
+		},
+		{
+			name: "errors and stringers",
+			entry: Entry{Time: encodeTime, Level: WarnLevel, Message: "m", Fields: []Field{
+				{"error", errors.New("boom")}, {"timeout", 1500 * time.Millisecond},
+			}},
+			expect: `{"time":"2024-03-01T12:30:45.123456789Z","level":"warn","msg":"m","error":"boom","timeout":"1.5s"}`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			JSONEncoder{}.Encode(&buf, test.entry)
+			if got := buf.String(); got != test.expect {
+				t.Errorf("got  %s\nwant %s", got, test.expect)
+			}
+		})
+	}
+}
+
+func TestJSONEncoderUnmarshalableValue(t *testing.T) {
+	var buf bytes.Buffer
+	JSONEncoder{}.Encode(&buf, Entry{Time: encodeTime, Level: DebugLevel, Message: "m", Fields: []Field{{"ch", make(chan int)}}})
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("invalid JSON %s: %v", buf.String(), err)
+	}
+	if s, ok := entry["ch"].(string); !ok || !strings.HasPrefix(s, "0x") {
+		t.Errorf("ch = %v, want the channel formatted as a string", entry["ch"])
+	}
+}
+
+func TestConsoleEncoder(t *testing.T) {
+	tests := []struct {
+		name   string
+		entry  Entry
+		expect string
+	}{
+		{
+			name:   "no fields",
+			entry:  Entry{Time: encodeTime, Level: InfoLevel, Message: "started"},
+			expect: "2024-03-01T12:30:45.123Z INFO  started",
+		},
+		{
+			name: "plain and quoted values",
+			entry: Entry{Time: encodeTime, Level: ErrorLevel, Message: "Query failed", Fields: []Field{
+				{"table", "users"}, {"error", errors.New("no such table")}, {"empty", ""}, {"expr", "a=b"}, {"n", 3},
+			}},
+			expect: `2024-03-01T12:30:45.123Z ERROR Query failed table=users error="no such table" empty="" expr="a=b" n=3`,
+		},
+		{
+			name:   "stringer",
+			entry:  Entry{Time: encodeTime, Level: WarnLevel, Message: "slow", Fields: []Field{{"took", 2 * time.Second}}},
+			expect: "2024-03-01T12:30:45.123Z WARN  slow took=2s",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			ConsoleEncoder{}.Encode(&buf, test.entry)
+			if got := buf.String(); got != test.expect {
+				t.Errorf("got  %s\nwant %s", got, test.expect)
+			}
+		})
+	}
+}
+
+func TestWithEncoder(t *testing.T) {
+	var buf bytes.Buffer
+	New(&buf, WithEncoder(ConsoleEncoder{})).With("user", 7).Info("Signed in")
+	if got, suffix := buf.String(), " INFO  Signed in user=7\n"; !strings.HasSuffix(got, suffix) {
+		t.Errorf("got %q, want a console line ending in %q", got, suffix)
+	}
+}
@@ -0,0 +1,194 @@
+// Package logger is a leveled, structured logger that writes key/value pairs
+// as JSON or as human-readable console lines.
+//
+//	log := logger.New(os.Stderr, logger.WithLevel(logger.InfoLevel))
+//	log.Info("Listening", "addr", addr)
+//	log.Ctx(ctx).Error("Query failed", "error", err)
+package logger
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Level is the severity of a message.
+type Level int32
+
+const (
+	DebugLevel Level = iota
+	InfoLevel
+	WarnLevel
+	ErrorLevel
+)
+
+func (l Level) String() string {
+	switch l {
+	case DebugLevel:
+		return "debug"
+	case InfoLevel:
+		return "info"
+	case WarnLevel:
+		return "warn"
+	default:
+		return "error"
+	}
+}
+
+// ParseLevel parses "debug", "info", "warn" or "error", ignoring case.
+func ParseLevel(s string) (Level, error) {
+	switch strings.ToLower(s) {
+	case "debug":
+		return DebugLevel, nil
+	case "info":
+		return InfoLevel, nil
+	case "warn", "warning":
+		return WarnLevel, nil
+	case "error":
+		return ErrorLevel, nil
+	}
+	return InfoLevel, fmt.Errorf("Unknown log level %q", s)
+}
+
+// UnmarshalText lets a Level be loaded by the config package.
+func (l *Level) UnmarshalText(text []byte) error {
+	level, err := ParseLevel(string(text))
+	if err != nil {
+		return err
+	}
+	*l = level
+	return nil
+}
+
+// Option configures New.
+type Option func(*core)
+
+// WithLevel sets the minimum level written. The default is InfoLevel.
+func WithLevel(level Level) Option {
+	return func(c *core) {
+		c.level = int32(level)
+	}
+}
+
+// WithEncoder sets how entries are formatted. The default is JSONEncoder.
+func WithEncoder(encoder Encoder) Option {
+	return func(c *core) {
+		c.encoder = encoder
+	}
+}
+
+// core is shared by a Logger and every logger derived from it with With.
+type core struct {
+	mu      sync.Mutex
+	out     io.Writer
+	level   int32
+	encoder Encoder
+}
+
+// Logger writes structured entries. It is safe for concurrent use.
+type Logger struct {
+	core   *core
+	fields []Field
+}
+
+// New returns a Logger writing to out.
+func New(out io.Writer, options ...Option) *Logger {
+	c := &core{out: out, level: int32(InfoLevel), encoder: JSONEncoder{}}
+	for _, option := range options {
+		option(c)
+	}
+	return &Logger{core: c}
+}
+
+var defaultLogger = New(os.Stderr)
+
+// Default returns the logger writing JSON at InfoLevel to standard error
+// that FromContext falls back to.
+func Default() *Logger {
+	return defaultLogger
+}
+
+// SetLevel changes the minimum level written by l and every logger sharing
+// its output.
+func (l *Logger) SetLevel(level Level) {
+	atomic.StoreInt32(&l.core.level, int32(level))
+}
+
+// Enabled reports whether messages at level are written.
+func (l *Logger) Enabled(level Level) bool {
+	return level >= Level(atomic.LoadInt32(&l.core.level))
+}
+
+// With returns a logger that adds the given key/value pairs to every entry.
+func (l *Logger) With(keyvals ...interface{}) *Logger {
+	fields := make([]Field, len(l.fields), len(l.fields)+len(keyvals)/2)
+	copy(fields, l.fields)
+	return &Logger{core: l.core, fields: append(fields, toFields(keyvals)...)}
+}
+
+func (l *Logger) Debug(msg string, keyvals ...interface{}) {
+	l.Log(DebugLevel, msg, keyvals...)
+}
+
+func (l *Logger) Info(msg string, keyvals ...interface{}) {
+	l.Log(InfoLevel, msg, keyvals...)
+}
+
+func (l *Logger) Warn(msg string, keyvals ...interface{}) {
+	l.Log(WarnLevel, msg, keyvals...)
+}
+
+func (l *Logger) Error(msg string, keyvals ...interface{}) {
+	l.Log(ErrorLevel, msg, keyvals...)
+}
+
+// Log writes msg at level with the logger's fields followed by keyvals,
+// which alternate between string keys and values.
+func (l *Logger) Log(level Level, msg string, keyvals ...interface{}) {
+	if !l.Enabled(level) {
+		return
+	}
+	entry := Entry{
+		Time:    time.Now(),
+		Level:   level,
+		Message: msg,
+		Fields:  append(append([]Field(nil), l.fields...), toFields(keyvals)...),
+	}
+
+	var buf bytes.Buffer
+	l.core.encoder.Encode(&buf, entry)
+	buf.WriteByte('\n')
+
+	l.core.mu.Lock()
+	defer l.core.mu.Unlock()
+	l.core.out.Write(buf.Bytes())
+}
+
+// Field is a key/value pair attached to an entry.
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// toFields pairs up keyvals. A key that is not a string is formatted with
+// fmt, and a trailing value without a key is logged under "!BADKEY".
+func toFields(keyvals []interface{}) []Field {
+	fields := make([]Field, 0, (len(keyvals)+1)/2)
+	for i := 0; i < len(keyvals); i += 2 {
+		if i+1 == len(keyvals) {
+			fields = append(fields, Field{Key: "!BADKEY", Value: keyvals[i]})
+			break
+		}
+		key, ok := keyvals[i].(string)
+		if !ok {
+			key = fmt.Sprint(keyvals[i])
+		}
+		fields = append(fields, Field{Key: key, Value: keyvals[i+1]})
+	}
+	return fields
+}
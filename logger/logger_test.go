@@ -0,0 +1,161 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// decode parses each JSON line written to buf.
+func decode(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+	var entries []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n") {
+		if line == "" {
+			continue
+		}
+		var entry map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("invalid JSON line %q: %v", line, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestLevelFiltering(t *testing.T) {
+	tests := []struct {
+		level Level
+		want  []string
+	}{
+		{DebugLevel, []string{"debug", "info", "warn", "error"}},
+		{InfoLevel, []string{"info", "warn", "error"}},
+		{WarnLevel, []string{"warn", "error"}},
+		{ErrorLevel, []string{"error"}},
+	}
+	for _, test := range tests {
+		t.Run(test.level.String(), func(t *testing.T) {
+			var buf bytes.Buffer
+			log := New(&buf, WithLevel(test.level))
+			log.Debug("m")
+			log.Info("m")
+			log.Warn("m")
+			log.Error("m")
+
+			entries := decode(t, &buf)
+			if len(entries) != len(test.want) {
+				t.Fatalf("wrote %d entries, want %d: %s", len(entries), len(test.want), buf.String())
+			}
+			for i, entry := range entries {
+				if entry["level"] != test.want[i] {
+					t.Errorf("entry %d level = %v, want %s", i, entry["level"], test.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSetLevelAffectsDerivedLoggers(t *testing.T) {
+	var buf bytes.Buffer
+	log := New(&buf)
+	child := log.With("component", "db")
+	if child.Enabled(DebugLevel) {
+		t.Fatal("debug enabled at the default level")
+	}
+	log.SetLevel(DebugLevel)
+	child.Debug("now visible")
+	if entries := decode(t, &buf); len(entries) != 1 {
+		t.Errorf("wrote %d entries, want the child's debug entry", len(entries))
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		input string
+		want  Level
+		err   bool
+	}{
+		{"debug", DebugLevel, false},
+		{"INFO", InfoLevel, false},
+		{"Warning", WarnLevel, false},
+		{"error", ErrorLevel, false},
+		{"verbose", InfoLevel, true},
+	}
+	for _, test := range tests {
+		level, err := ParseLevel(test.input)
+		if level != test.want || (err != nil) != test.err {
+			t.Errorf("ParseLevel(%q) = %v, %v; want %v, error %v", test.input, level, err, test.want, test.err)
+		}
+	}
+
+	var level Level
+	if err := level.UnmarshalText([]byte("warn")); err != nil || level != WarnLevel {
+		t.Errorf("UnmarshalText = %v, %v; want warn", level, err)
+	}
+}
+
+func TestWithInheritsFields(t *testing.T) {
+	var buf bytes.Buffer
+	parent := New(&buf).With("service", "api")
+	first := parent.With("component", "db")
+	second := parent.With("component", "cache")
+	first.Info("first", "query", "select")
+	second.Info("second")
+	parent.Info("parent")
+
+	entries := decode(t, &buf)
+	tests := []struct {
+		fields map[string]interface{}
+		absent []string
+	}{
+		{map[string]interface{}{"service": "api", "component": "db", "query": "select"}, nil},
+		{map[string]interface{}{"service": "api", "component": "cache"}, []string{"query"}},
+		{map[string]interface{}{"service": "api"}, []string{"component", "query"}},
+	}
+	for i, test := range tests {
+		for key, want := range test.fields {
+			if entries[i][key] != want {
+				t.Errorf("entry %d: %s = %v, want %v", i, key, entries[i][key], want)
+			}
+		}
+		for _, key := range test.absent {
+			if _, ok := entries[i][key]; ok {
+				t.Errorf("entry %d: unexpected %s field", i, key)
+			}
+		}
+	}
+}
+
+func TestToFields(t *testing.T) {
+	fields := toFields([]interface{}{"a", 1, 2, "b", "dangling"})
+	want := []Field{{"a", 1}, {"2", "b"}, {"!BADKEY", "dangling"}}
+	if len(fields) != len(want) {
+		t.Fatalf("fields = %v, want %v", fields, want)
+	}
+	for i := range want {
+		if fields[i] != want[i] {
+			t.Errorf("field %d = %v, want %v", i, fields[i], want[i])
+		}
+	}
+}
+
+func TestConcurrentWrites(t *testing.T) {
+	var buf bytes.Buffer
+	log := New(&buf)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				log.With("worker", i).Info("tick", "n", j)
+			}
+		}(i)
+	}
+	wg.Wait()
+	if entries := decode(t, &buf); len(entries) != 400 {
+		t.Errorf("wrote %d entries, want 400", len(entries))
+	}
+}
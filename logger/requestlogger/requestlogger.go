@@ -0,0 +1,37 @@
+// Package requestlogger adapts a logger.Logger to the request package's
+// Logger.
+package requestlogger
+
+import (
+	"github.com/metamemelord/go-utilities/http/request"
+	"github.com/metamemelord/go-utilities/logger"
+)
+
+type adapter struct {
+	logger *logger.Logger
+}
+
+// New returns a request.Logger that writes to l.
+func New(l *logger.Logger) request.Logger {
+	return adapter{logger: l}
+}
+
+func (a adapter) Debug(msg string, fields ...request.Field) {
+	a.logger.Debug(msg, keyvals(fields)...)
+}
+
+func (a adapter) Info(msg string, fields ...request.Field) {
+	a.logger.Info(msg, keyvals(fields)...)
+}
+
+func (a adapter) Error(msg string, fields ...request.Field) {
+	a.logger.Error(msg, keyvals(fields)...)
+}
+
+func keyvals(fields []request.Field) []interface{} {
+	converted := make([]interface{}, 0, 2*len(fields))
+	for _, field := range fields {
+		converted = append(converted, field.Key, field.Value)
+	}
+	return converted
+}
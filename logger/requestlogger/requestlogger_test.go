@@ -0,0 +1,39 @@
+package requestlogger
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/metamemelord/go-utilities/http/request"
+	"github.com/metamemelord/go-utilities/logger"
+)
+
+func TestAdapter(t *testing.T) {
+	var buf bytes.Buffer
+	log := New(logger.New(&buf, logger.WithLevel(logger.InfoLevel)).With("service", "api"))
+	log.Debug("dropped", request.F("url", "/a"))
+	log.Info("Request completed", request.F("status", 200), request.F("url", "/b"))
+	log.Error("Request failed", request.F("attempt", 2))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("wrote %d lines, want 2: %s", len(lines), buf.String())
+	}
+	tests := []map[string]interface{}{
+		{"level": "info", "msg": "Request completed", "service": "api", "status": 200.0, "url": "/b"},
+		{"level": "error", "msg": "Request failed", "service": "api", "attempt": 2.0},
+	}
+	for i, want := range tests {
+		var entry map[string]interface{}
+		if err := json.Unmarshal([]byte(lines[i]), &entry); err != nil {
+			t.Fatal(err)
+		}
+		for key, value := range want {
+			if entry[key] != value {
+				t.Errorf("line %d: %s = %v, want %v", i, key, entry[key], value)
+			}
+		}
+	}
+}
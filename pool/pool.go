@@ -0,0 +1,150 @@
+// Package pool runs tasks on a bounded set of worker goroutines and collects
+// their results.
+package pool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+)
+
+// ErrClosed is returned by Submit once the pool has been shut down.
+var ErrClosed = errors.New("Pool is closed")
+
+// PanicError is the error recorded for a task that panicked.
+type PanicError struct {
+	Value interface{}
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("Task panicked: %v", e.Value)
+}
+
+// Task is a unit of work. ctx is canceled when the pool is shut down before
+// the task finishes.
+type Task[T any] func(ctx context.Context) (T, error)
+
+// Result is the outcome of a task. Index is the order in which the task was
+// submitted, starting at 0.
+type Result[T any] struct {
+	Index int
+	Value T
+	Err   error
+}
+
+type job[T any] struct {
+	index int
+	task  Task[T]
+}
+
+// Pool runs submitted tasks on a fixed number of workers.
+type Pool[T any] struct {
+	jobs    chan job[T]
+	ctx     context.Context
+	cancel  context.CancelFunc
+	workers sync.WaitGroup
+	done    chan struct{}
+
+	submitting sync.RWMutex
+	closed     bool
+
+	mutex   sync.Mutex
+	results []Result[T]
+}
+
+// New starts a pool with the given number of workers. Less than one worker
+// is treated as one.
+func New[T any](workers int) *Pool[T] {
+	if workers < 1 {
+		workers = 1
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &Pool[T]{
+		jobs:   make(chan job[T]),
+		ctx:    ctx,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	p.workers.Add(workers)
+	for i := 0; i < workers; i++ {
+		go p.work()
+	}
+	go func() {
+		p.workers.Wait()
+		close(p.done)
+	}()
+	return p
+}
+
+// Submit queues task and blocks until a worker picks it up. It returns
+// ErrClosed if the pool has been shut down.
+func (p *Pool[T]) Submit(task Task[T]) error {
+	p.submitting.RLock()
+	defer p.submitting.RUnlock()
+	if p.closed {
+		return ErrClosed
+	}
+
+	p.mutex.Lock()
+	index := len(p.results)
+	p.results = append(p.results, Result[T]{Index: index})
+	p.mutex.Unlock()
+
+	p.jobs <- job[T]{index: index, task: task}
+	return nil
+}
+
+// Wait stops accepting tasks, waits for every submitted task to finish and
+// returns their results in submission order.
+func (p *Pool[T]) Wait() []Result[T] {
+	results, _ := p.Shutdown(context.Background())
+	return results
+}
+
+// Shutdown stops accepting tasks and waits for submitted tasks to finish. If
+// ctx is done first, the context of running tasks is canceled and Shutdown
+// returns ctx's error along with the results collected so far; tasks that
+// have not finished have a zero Result apart from its Index.
+func (p *Pool[T]) Shutdown(ctx context.Context) ([]Result[T], error) {
+	p.submitting.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.jobs)
+	}
+	p.submitting.Unlock()
+
+	var err error
+	select {
+	case <-p.done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	p.cancel()
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return append([]Result[T](nil), p.results...), err
+}
+
+func (p *Pool[T]) work() {
+	defer p.workers.Done()
+	for job := range p.jobs {
+		value, err := p.run(job.task)
+		p.mutex.Lock()
+		p.results[job.index] = Result[T]{Index: job.index, Value: value, Err: err}
+		p.mutex.Unlock()
+	}
+}
+
+// run calls task, turning a panic into a *PanicError.
+func (p *Pool[T]) run(task Task[T]) (value T, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &PanicError{Value: r, Stack: debug.Stack()}
+		}
+	}()
+	return task(p.ctx)
+}
@@ -0,0 +1,111 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestConcurrencyBound(t *testing.T) {
+	var running, peak int32
+	p := New[int](3)
+	for i := 0; i < 12; i++ {
+		err := p.Submit(func(context.Context) (int, error) {
+			n := atomic.AddInt32(&running, 1)
+			defer atomic.AddInt32(&running, -1)
+			for {
+				current := atomic.LoadInt32(&peak)
+				if n <= current || atomic.CompareAndSwapInt32(&peak, current, n) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			return 0, nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	p.Wait()
+	if peak > 3 {
+		t.Errorf("%d tasks ran at once, want at most 3", peak)
+	}
+}
+
+func TestResultsKeepSubmissionOrder(t *testing.T) {
+	p := New[int](4)
+	for i := 0; i < 20; i++ {
+		i := i
+		p.Submit(func(context.Context) (int, error) {
+			time.Sleep(time.Duration(20-i) * time.Millisecond / 4)
+			return i * i, nil
+		})
+	}
+	results := p.Wait()
+	if len(results) != 20 {
+		t.Fatalf("got %d results, want 20", len(results))
+	}
+	for i, result := range results {
+		if result.Index != i || result.Value != i*i || result.Err != nil {
+			t.Errorf("result %d = %+v, want value %d", i, result, i*i)
+		}
+	}
+}
+
+func TestFailingAndPanickingTasks(t *testing.T) {
+	failure := errors.New("failed")
+	p := New[string](2)
+	p.Submit(func(context.Context) (string, error) { return "", failure })
+	p.Submit(func(context.Context) (string, error) { panic("boom") })
+	p.Submit(func(context.Context) (string, error) { return "ok", nil })
+	results := p.Wait()
+
+	if results[0].Err != failure {
+		t.Errorf("failing task: err = %v, want %v", results[0].Err, failure)
+	}
+	var panicked *PanicError
+	if !errors.As(results[1].Err, &panicked) || panicked.Value != "boom" || len(panicked.Stack) == 0 {
+		t.Errorf("panicking task: err = %v, want a *PanicError with a stack", results[1].Err)
+	}
+	if results[2].Value != "ok" || results[2].Err != nil {
+		t.Errorf("task after the panic = %+v, want ok", results[2])
+	}
+}
+
+func TestSubmitAfterClose(t *testing.T) {
+	p := New[int](1)
+	p.Wait()
+	if err := p.Submit(func(context.Context) (int, error) { return 0, nil }); err != ErrClosed {
+		t.Errorf("Submit after Wait = %v, want ErrClosed", err)
+	}
+	if results := p.Wait(); len(results) != 0 {
+		t.Errorf("second Wait returned %d results, want none", len(results))
+	}
+}
+
+func TestShutdownTimeout(t *testing.T) {
+	p := New[int](1)
+	cancelled := make(chan struct{})
+	p.Submit(func(ctx context.Context) (int, error) {
+		<-ctx.Done()
+		close(cancelled)
+		return 0, ctx.Err()
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	results, err := p.Shutdown(ctx)
+	if err != context.DeadlineExceeded {
+		t.Errorf("Shutdown = %v, want context.DeadlineExceeded", err)
+	}
+	if len(results) != 1 || results[0].Index != 0 {
+		t.Errorf("results = %+v, want the unfinished task's index", results)
+	}
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Error("running task's context was not cancelled")
+	}
+}
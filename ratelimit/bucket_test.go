@@ -0,0 +1,51 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestTokenBucketBurst(t *testing.T) {
+	bucket := NewTokenBucket(1, 3)
+	for i := 0; i < 3; i++ {
+		if !bucket.Allow() {
+			t.Fatalf("event %d of the burst was refused", i+1)
+		}
+	}
+	if bucket.Allow() {
+		t.Error("event beyond the burst was allowed")
+	}
+}
+
+func TestTokenBucketWait(t *testing.T) {
+	bucket := NewTokenBucket(20, 1)
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := bucket.Wait(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 80*time.Millisecond {
+		t.Errorf("3 events at 20/s with a burst of 1 took %v, want at least 100ms", elapsed)
+	}
+}
+
+func TestTokenBucketZeroRate(t *testing.T) {
+	for _, rate := range []float64{0, -1} {
+		bucket := NewTokenBucket(rate, 1)
+		if !bucket.Allow() {
+			t.Fatalf("rate %v: burst was refused", rate)
+		}
+		if bucket.Allow() {
+			t.Errorf("rate %v: bucket refilled", rate)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		err := bucket.Wait(ctx)
+		cancel()
+		if err != context.DeadlineExceeded {
+			t.Errorf("rate %v: Wait = %v, want it to block until the context is done", rate, err)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+// Package ratelimit provides token bucket and sliding window rate limiters,
+// and keyed limiters that keep one limiter per host, user or other key.
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Limiter decides whether an event may happen now.
+type Limiter interface {
+	// Allow reports whether an event may happen now, consuming capacity if
+	// so. It never blocks.
+	Allow() bool
+	// Wait blocks until an event may happen or ctx is done, in which case it
+	// returns ctx's error without consuming capacity.
+	Wait(ctx context.Context) error
+}
+
+// sleep waits for delay or until ctx is done.
+func sleep(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Keyed holds a separate limiter for every key, created on first use.
+type Keyed[K comparable] struct {
+	mu         sync.Mutex
+	newLimiter func(key K) Limiter
+	limiters   map[K]Limiter
+}
+
+// NewKeyed returns a keyed limiter that calls newLimiter to create the
+// limiter for a key the first time it is seen. Limiters are kept for the
+// life of the Keyed, so keys should come from a bounded set.
+func NewKeyed[K comparable](newLimiter func(key K) Limiter) *Keyed[K] {
+	return &Keyed[K]{newLimiter: newLimiter, limiters: make(map[K]Limiter)}
+}
+
+// Get returns the limiter for key.
+func (k *Keyed[K]) Get(key K) Limiter {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	limiter, ok := k.limiters[key]
+	if !ok {
+		limiter = k.newLimiter(key)
+		k.limiters[key] = limiter
+	}
+	return limiter
+}
+
+// Allow reports whether an event for key may happen now.
+func (k *Keyed[K]) Allow(key K) bool {
+	return k.Get(key).Allow()
+}
+
+// Wait blocks until an event for key may happen or ctx is done.
+func (k *Keyed[K]) Wait(ctx context.Context, key K) error {
+	return k.Get(key).Wait(ctx)
+}
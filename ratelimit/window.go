@@ -0,0 +1,66 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// SlidingWindow allows at most limit events in any period of length window.
+// Unlike a token bucket it never allows a burst above limit, at the cost of
+// remembering the time of the last limit events.
+type SlidingWindow struct {
+	mu     sync.Mutex
+	limit  int
+	window time.Duration
+	events []time.Time
+}
+
+// NewSlidingWindow returns a limiter allowing limit events per window. A
+// limit less than one is treated as one.
+func NewSlidingWindow(limit int, window time.Duration) *SlidingWindow {
+	if limit < 1 {
+		limit = 1
+	}
+	return &SlidingWindow{limit: limit, window: window, events: make([]time.Time, 0, limit)}
+}
+
+// take records an event if the window has room, and otherwise returns how
+// long until the oldest event leaves the window.
+func (w *SlidingWindow) take() (bool, time.Duration) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	now := time.Now()
+	start := now.Add(-w.window)
+	expired := 0
+	for expired < len(w.events) && !w.events[expired].After(start) {
+		expired++
+	}
+	w.events = append(w.events[:0], w.events[expired:]...)
+
+	if len(w.events) < w.limit {
+		w.events = append(w.events, now)
+		return true, 0
+	}
+	return false, w.events[0].Sub(start)
+}
+
+// Allow implements Limiter.
+func (w *SlidingWindow) Allow() bool {
+	allowed, _ := w.take()
+	return allowed
+}
+
+// Wait implements Limiter.
+func (w *SlidingWindow) Wait(ctx context.Context) error {
+	for {
+		allowed, delay := w.take()
+		if allowed {
+			return nil
+		}
+		if err := sleep(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package retry
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Backoff returns how long to wait after the given failed attempt, where
+// attempt 1 is the first one.
+type Backoff func(attempt int) time.Duration
+
+// Constant waits the same delay after every attempt.
+func Constant(delay time.Duration) Backoff {
+	return func(int) time.Duration {
+		return delay
+	}
+}
+
+// Exponential doubles the delay after every attempt, starting at base, and
+// never waits longer than max.
+func Exponential(base, max time.Duration) Backoff {
+	return func(attempt int) time.Duration {
+		return exponentialDelay(base, max, attempt)
+	}
+}
+
+// ExponentialJitter waits a random delay between zero and the delay
+// Exponential would use, spreading out retries from many clients.
+func ExponentialJitter(base, max time.Duration) Backoff {
+	return func(attempt int) time.Duration {
+		delay := exponentialDelay(base, max, attempt)
+		if delay <= 0 {
+			return 0
+		}
+		jitterMutex.Lock()
+		defer jitterMutex.Unlock()
+		return time.Duration(jitter.Int63n(int64(delay) + 1))
+	}
+}
+
+var (
+	jitterMutex sync.Mutex
+	jitter      = rand.New(rand.NewSource(time.Now().UnixNano()))
+)
+
+func exponentialDelay(base, max time.Duration, attempt int) time.Duration {
+	delay := base
+	for i := 1; i < attempt && delay < max; i++ {
+		delay *= 2
+	}
+	if delay > max {
+		return max
+	}
+	return delay
+}
@@ -0,0 +1,161 @@
+// Package retry runs an operation until it succeeds, a retry limit is hit or
+// its context is done, waiting between attempts according to a Backoff.
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Error is returned by Do when every attempt failed with a retryable error.
+type Error struct {
+	Attempts int
+	Err      error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("Gave up after %d attempts: %v", e.Attempts, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+type unrecoverable struct {
+	err error
+}
+
+func (u unrecoverable) Error() string {
+	return u.err.Error()
+}
+
+func (u unrecoverable) Unwrap() error {
+	return u.err
+}
+
+// Unrecoverable marks err so that Do returns it right away without retrying.
+func Unrecoverable(err error) error {
+	if err == nil {
+		return nil
+	}
+	return unrecoverable{err: err}
+}
+
+// RetryAfter can be implemented by errors that know how long to wait before
+// the next attempt, such as a rate limited response. A positive RetryAfter
+// overrides the backoff delay for that attempt.
+type RetryAfter interface {
+	RetryAfter() time.Duration
+}
+
+type config struct {
+	attempts int
+	backoff  Backoff
+	retryIf  func(error) bool
+	onRetry  func(attempt int, err error, delay time.Duration)
+}
+
+// Option configures Do.
+type Option func(*config)
+
+// Attempts sets the maximum number of times the operation is run, including
+// the first attempt. The default is 3.
+func Attempts(n int) Option {
+	return func(c *config) {
+		if n < 1 {
+			n = 1
+		}
+		c.attempts = n
+	}
+}
+
+// WithBackoff sets the delay strategy used between attempts. The default is
+// ExponentialJitter(100ms, 10s).
+func WithBackoff(backoff Backoff) Option {
+	return func(c *config) {
+		c.backoff = backoff
+	}
+}
+
+// RetryIf sets which errors are retried. By default every error is.
+func RetryIf(retryIf func(error) bool) Option {
+	return func(c *config) {
+		c.retryIf = retryIf
+	}
+}
+
+// OnRetry registers fn to be called after a failed attempt that is about to
+// be retried, before waiting delay. attempt is the number of the failed
+// attempt, starting at 1.
+func OnRetry(fn func(attempt int, err error, delay time.Duration)) Option {
+	return func(c *config) {
+		c.onRetry = fn
+	}
+}
+
+// Do calls fn until it returns nil, returns an error that is not retried, or
+// the attempts run out, in which case the last error is returned wrapped in
+// an *Error. If ctx is done while waiting between attempts, its error is
+// returned.
+func Do(ctx context.Context, fn func() error, options ...Option) error {
+	c := config{
+		attempts: 3,
+		backoff:  ExponentialJitter(100*time.Millisecond, 10*time.Second),
+	}
+	for _, option := range options {
+		option(&c)
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil {
+			return nil
+		}
+		var stop unrecoverable
+		if errors.As(err, &stop) {
+			return stop.err
+		}
+		if c.retryIf != nil && !c.retryIf(err) {
+			return err
+		}
+		if attempt >= c.attempts {
+			return &Error{Attempts: attempt, Err: err}
+		}
+		delay := c.delay(attempt, err)
+		if c.onRetry != nil {
+			c.onRetry(attempt, err, delay)
+		}
+		if err = wait(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
+
+func (c *config) delay(attempt int, err error) time.Duration {
+	var after RetryAfter
+	if errors.As(err, &after) {
+		if delay := after.RetryAfter(); delay > 0 {
+			return delay
+		}
+	}
+	if c.backoff == nil {
+		return 0
+	}
+	return c.backoff(attempt)
+}
+
+func wait(ctx context.Context, delay time.Duration) error {
+	if delay <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
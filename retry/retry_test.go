@@ -0,0 +1,169 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+var errFailed = errors.New("failed")
+
+type retryAfterError struct {
+	delay time.Duration
+}
+
+func (e retryAfterError) Error() string { return "rate limited" }
+
+func (e retryAfterError) RetryAfter() time.Duration { return e.delay }
+
+func TestDo(t *testing.T) {
+	tests := []struct {
+		name      string
+		failures  int
+		err       error
+		options   []Option
+		wantCalls int
+		wantErr   error
+		exhausted bool
+	}{
+		{name: "succeeds first time", wantCalls: 1},
+		{name: "succeeds after failures", failures: 2, err: errFailed, wantCalls: 3},
+		{name: "gives up after attempts", failures: 10, err: errFailed, options: []Option{Attempts(4)}, wantCalls: 4, wantErr: errFailed, exhausted: true},
+		{name: "at least one attempt", failures: 10, err: errFailed, options: []Option{Attempts(0)}, wantCalls: 1, wantErr: errFailed, exhausted: true},
+		{name: "stops on unrecoverable", failures: 10, err: Unrecoverable(errFailed), wantCalls: 1, wantErr: errFailed},
+		{name: "stops when RetryIf rejects", failures: 10, err: errFailed, options: []Option{RetryIf(func(error) bool { return false })}, wantCalls: 1, wantErr: errFailed},
+		{name: "retries when RetryIf accepts", failures: 1, err: errFailed, options: []Option{RetryIf(func(err error) bool { return err == errFailed })}, wantCalls: 2},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			calls := 0
+			fn := func() error {
+				calls++
+				if calls <= test.failures {
+					return test.err
+				}
+				return nil
+			}
+			options := append([]Option{WithBackoff(Constant(0))}, test.options...)
+			err := Do(context.Background(), fn, options...)
+			if calls != test.wantCalls {
+				t.Errorf("fn called %d times, want %d", calls, test.wantCalls)
+			}
+			if !errors.Is(err, test.wantErr) || (err == nil) != (test.wantErr == nil) {
+				t.Errorf("err = %v, want %v", err, test.wantErr)
+			}
+			var retryError *Error
+			if errors.As(err, &retryError) != test.exhausted {
+				t.Errorf("err = %v, exhausted = %v", err, test.exhausted)
+			} else if test.exhausted && retryError.Attempts != test.wantCalls {
+				t.Errorf("Error.Attempts = %d, want %d", retryError.Attempts, test.wantCalls)
+			}
+		})
+	}
+}
+
+func TestDoOnRetry(t *testing.T) {
+	type call struct {
+		attempt int
+		err     error
+		delay   time.Duration
+	}
+	var calls []call
+	Do(context.Background(), func() error { return errFailed },
+		Attempts(3),
+		WithBackoff(func(attempt int) time.Duration { return time.Duration(attempt) * time.Microsecond }),
+		OnRetry(func(attempt int, err error, delay time.Duration) {
+			calls = append(calls, call{attempt, err, delay})
+		}),
+	)
+	want := []call{{1, errFailed, time.Microsecond}, {2, errFailed, 2 * time.Microsecond}}
+	if len(calls) != len(want) {
+		t.Fatalf("OnRetry called %d times, want %d", len(calls), len(want))
+	}
+	for i := range want {
+		if calls[i] != want[i] {
+			t.Errorf("OnRetry call %d = %+v, want %+v", i+1, calls[i], want[i])
+		}
+	}
+}
+
+func TestDoRetryAfter(t *testing.T) {
+	tests := []struct {
+		name  string
+		after time.Duration
+		want  time.Duration
+	}{
+		{name: "overrides the backoff", after: 3 * time.Millisecond, want: 3 * time.Millisecond},
+		{name: "zero keeps the backoff", after: 0, want: time.Millisecond},
+		{name: "negative keeps the backoff", after: -time.Second, want: time.Millisecond},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var delay time.Duration
+			Do(context.Background(), func() error { return retryAfterError{test.after} },
+				Attempts(2),
+				WithBackoff(Constant(time.Millisecond)),
+				OnRetry(func(_ int, _ error, d time.Duration) { delay = d }),
+			)
+			if delay != test.want {
+				t.Errorf("delay = %v, want %v", delay, test.want)
+			}
+		})
+	}
+}
+
+func TestDoCancelledDuringBackoff(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	start := time.Now()
+	err := Do(ctx, func() error { return errFailed },
+		Attempts(5),
+		WithBackoff(Constant(time.Hour)),
+		OnRetry(func(int, error, time.Duration) {
+			calls++
+			time.AfterFunc(10*time.Millisecond, cancel)
+		}),
+	)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+	if calls != 1 || time.Since(start) > time.Second {
+		t.Errorf("gave up after %d retries and %v, want during the first backoff", calls, time.Since(start))
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		name    string
+		backoff Backoff
+		want    []time.Duration
+	}{
+		{name: "constant", backoff: Constant(time.Second), want: []time.Duration{time.Second, time.Second, time.Second}},
+		{name: "exponential", backoff: Exponential(time.Second, 5*time.Second), want: []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for i, want := range test.want {
+				if got := test.backoff(i + 1); got != want {
+					t.Errorf("attempt %d: delay = %v, want %v", i+1, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestExponentialJitterBounds(t *testing.T) {
+	backoff := ExponentialJitter(10*time.Millisecond, 50*time.Millisecond)
+	for attempt := 1; attempt <= 5; attempt++ {
+		limit := exponentialDelay(10*time.Millisecond, 50*time.Millisecond, attempt)
+		for i := 0; i < 100; i++ {
+			if delay := backoff(attempt); delay < 0 || delay > limit {
+				t.Fatalf("attempt %d: delay %v outside [0, %v]", attempt, delay, limit)
+			}
+		}
+	}
+	if delay := ExponentialJitter(0, time.Second)(1); delay != 0 {
+		t.Errorf("zero base: delay = %v, want 0", delay)
+	}
+}